@@ -0,0 +1,176 @@
+//Command healthprobe checks the simple or detailed health of a service for
+//container health checks, such as Docker HEALTHCHECK in images without curl:
+//
+//  HEALTHCHECK CMD ["/healthprobe", "-url", "http://localhost:8080/health"]
+//
+//The exit code is 0 for OK, 1 for WARN and 2 for CRIT. A status that is not
+//more critical than -min-status exits with 0. Any error, such as failing to
+//connect or parse the response, is CRIT.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+)
+
+//exitCodes are the exit codes of the statuses
+var exitCodes = map[string]int{
+	health.OK:   0,
+	health.WARN: 1,
+	health.CRIT: 2,
+}
+
+//result is the JSON output of the probe
+type result struct {
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Revision   string `json:"revision,omitempty"`
+	Details    string `json:"details,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type options struct {
+	url        string
+	timeout    time.Duration
+	unixSocket string
+	insecure   bool
+	caCert     string
+	cert       string
+	key        string
+	minStatus  string
+	jsonOutput bool
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+//run probes the health with the command-line arguments and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	opts := options{}
+	fs := flag.NewFlagSet("healthprobe", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.url, "url", "http://localhost:8080/health", "URL of the simple or detailed health")
+	fs.DurationVar(&opts.timeout, "timeout", 5*time.Second, "Timeout of the request")
+	fs.StringVar(&opts.unixSocket, "unix-socket", "", "Path of a Unix socket to connect to instead of the URL host")
+	fs.BoolVar(&opts.insecure, "insecure", false, "Skip verifying the TLS certificate of the server")
+	fs.StringVar(&opts.caCert, "ca-cert", "", "PEM file of the CA certificates to verify the server")
+	fs.StringVar(&opts.cert, "cert", "", "PEM file of the client certificate")
+	fs.StringVar(&opts.key, "key", "", "PEM file of the client certificate key")
+	fs.StringVar(&opts.minStatus, "min-status", health.OK, "Most critical status that still exits with 0: OK, WARN or CRIT")
+	fs.BoolVar(&opts.jsonOutput, "json", false, "Print the result as JSON")
+	if err := fs.Parse(args); err != nil {
+		return exitCodes[health.CRIT]
+	}
+	minStatus := health.NormalizeStatus(opts.minStatus)
+	if minStatus == "" {
+		fmt.Fprintf(stderr, "Invalid -min-status `%s`\n", opts.minStatus)
+		return exitCodes[health.CRIT]
+	}
+
+	r := probe(opts)
+	if opts.jsonOutput {
+		json.NewEncoder(stdout).Encode(r)
+	} else {
+		fmt.Fprintf(stdout, "status=%s status_code=%d version=%s revision=%s", r.Status, r.StatusCode, r.Version, r.Revision)
+		if r.Details != "" {
+			fmt.Fprintf(stdout, " details=%q", r.Details)
+		}
+		if r.Error != "" {
+			fmt.Fprintf(stdout, " error=%q", r.Error)
+		}
+		fmt.Fprintln(stdout)
+	}
+	if !health.IsMoreCritical(r.Status, minStatus) {
+		return 0
+	}
+	return exitCodes[r.Status]
+}
+
+//probe requests the health and converts it into the result. Any error is CRIT.
+func probe(opts options) *result {
+	r := &result{URL: opts.url, Status: health.CRIT}
+	transport, err := newTransport(opts)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	to := health.TransportOptions{Timeout: opts.timeout, Transport: transport}
+	resp, err := to.Get(context.Background(), opts.url)
+	if err != nil {
+		r.Error = "Error connecting to `" + opts.url + "`: " + err.Error()
+		return r
+	}
+	defer resp.Body.Close()
+	r.StatusCode = resp.StatusCode
+
+	//A failing health, such as a draining server, may respond with an error code
+	//and a valid health document, so the body is parsed regardless of the code
+	h, err := health.DecodeHealth(resp.Body)
+	if err != nil {
+		r.Error = fmt.Sprintf("Status code: %d, response body is not a health document: %s", resp.StatusCode, err.Error())
+		return r
+	}
+	r.Version = h.Version
+	r.Revision = h.Revision
+	r.Details = h.Details
+	if r.Details == "" {
+		r.Details = h.StatusReason
+	}
+	if status := health.NormalizeStatus(h.Status); status != "" {
+		r.Status = status
+	} else {
+		r.Error = "Response body has unknown status `" + h.Status + "`"
+	}
+	if resp.StatusCode >= 300 && r.Status != health.CRIT {
+		r.Status = health.CRIT
+		r.Error = fmt.Sprintf("Status code: %d", resp.StatusCode)
+	}
+	return r
+}
+
+func newTransport(opts options) (*http.Transport, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.insecure}
+	if opts.caCert != "" {
+		pem, err := ioutil.ReadFile(opts.caCert)
+		if err != nil {
+			return nil, errors.New("Error reading the CA certificates: " + err.Error())
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("No CA certificate is found in " + opts.caCert)
+		}
+	}
+	if opts.cert != "" || opts.key != "" {
+		cert, err := tls.LoadX509KeyPair(opts.cert, opts.key)
+		if err != nil {
+			return nil, errors.New("Error loading the client certificate: " + err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment}
+	if opts.unixSocket != "" {
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", opts.unixSocket)
+		}
+	}
+	return transport, nil
+}
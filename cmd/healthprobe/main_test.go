@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestHealthProbe(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Health Probe Suite")
+}
+
+var _ = Describe("healthprobe", func() {
+	var ts *httptest.Server
+
+	BeforeEach(func() {
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/ok":
+				fmt.Fprint(w, `{"status":"OK","version":"1.0.0","revision":"abc"}`)
+			case "/warn":
+				fmt.Fprint(w, `{"status":"WARN","version":"1.0.0","statusReason":"WARN: some dependency is WARN"}`)
+			case "/draining":
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprint(w, `{"status":"CRIT","details":"draining"}`)
+			case "/slow":
+				time.Sleep(100 * time.Millisecond)
+				fmt.Fprint(w, `{"status":"OK"}`)
+			default:
+				fmt.Fprint(w, "not json")
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	probeURL := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		code := run(args, &stdout, &stderr)
+		return code, stdout.String() + stderr.String()
+	}
+
+	It("exits with the code of the status", func() {
+		code, out := probeURL("-url", ts.URL+"/ok")
+		Expect(code).To(Equal(0))
+		Expect(out).To(Equal("status=OK status_code=200 version=1.0.0 revision=abc\n"))
+
+		code, out = probeURL("-url", ts.URL+"/warn")
+		Expect(code).To(Equal(1))
+		Expect(out).To(ContainSubstring(`details="WARN: some dependency is WARN"`))
+
+		code, out = probeURL("-url", ts.URL+"/draining")
+		Expect(code).To(Equal(2))
+		Expect(out).To(ContainSubstring(`details="draining"`))
+	})
+
+	It("accepts the statuses up to the minimum status", func() {
+		code, _ := probeURL("-url", ts.URL+"/warn", "-min-status", "warn")
+		Expect(code).To(Equal(0))
+		code, _ = probeURL("-url", ts.URL+"/draining", "-min-status", "WARN")
+		Expect(code).To(Equal(2))
+		code, out := probeURL("-min-status", "bad")
+		Expect(code).To(Equal(2))
+		Expect(out).To(ContainSubstring("Invalid -min-status `bad`"))
+	})
+
+	It("is CRIT on errors", func() {
+		code, out := probeURL("-url", ts.URL+"/other")
+		Expect(code).To(Equal(2))
+		Expect(out).To(ContainSubstring("response body is not a health document"))
+
+		code, out = probeURL("-url", ts.URL+"/slow", "-timeout", "10ms")
+		Expect(code).To(Equal(2))
+		Expect(out).To(ContainSubstring("Error connecting to"))
+	})
+
+	It("prints the result as JSON", func() {
+		code, out := probeURL("-url", ts.URL+"/ok", "-json")
+		Expect(code).To(Equal(0))
+		var r result
+		Expect(json.Unmarshal([]byte(out), &r)).To(Succeed())
+		Expect(r).To(Equal(result{URL: ts.URL + "/ok", Status: health.OK, StatusCode: 200, Version: "1.0.0", Revision: "abc"}))
+	})
+
+	It("connects to a Unix socket", func() {
+		dir, err := ioutil.TempDir("", "healthprobe")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		socket := filepath.Join(dir, "health.sock")
+		ln, err := net.Listen("unix", socket)
+		Expect(err).NotTo(HaveOccurred())
+		srv := &http.Server{Handler: ts.Config.Handler}
+		go srv.Serve(ln)
+		defer srv.Close()
+
+		code, _ := probeURL("-url", "http://unix/ok", "-unix-socket", socket)
+		Expect(code).To(Equal(0))
+	})
+
+	It("fails with invalid TLS options", func() {
+		code, out := probeURL("-url", ts.URL+"/ok", "-ca-cert", "/nonexistent.pem")
+		Expect(code).To(Equal(2))
+		Expect(out).To(ContainSubstring("Error reading the CA certificates"))
+	})
+})
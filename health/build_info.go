@@ -0,0 +1,77 @@
+package health
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+)
+
+//These can be set at build time with -ldflags, and they take precedence over
+//the Go build info. For example:
+//  go build -ldflags "-X github.com/coupa/foundation-go/health.BuildVersion=1.2.0
+//    -X github.com/coupa/foundation-go/health.BuildRevision=$(git rev-parse HEAD)
+//    -X github.com/coupa/foundation-go/health.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	BuildVersion  string
+	BuildRevision string
+	BuildTime     string
+
+	buildInfoOnce sync.Once
+	buildInfo     *BuildInfo
+)
+
+//BuildInfo describes how the application binary was built
+type BuildInfo struct {
+	GoVersion string `json:"goVersion"`
+	//Path is the main package path and Module is the main module path
+	Path     string `json:"path,omitempty"`
+	Module   string `json:"module,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	//Modified is true when the source had uncommitted changes
+	Modified bool   `json:"modified,omitempty"`
+	Time     string `json:"time,omitempty"`
+}
+
+//ReadBuildInfo reads the build info from the -ldflags variables and the Go build
+//info that is embedded in the binary, which has the VCS data since Go 1.18
+func ReadBuildInfo() *BuildInfo {
+	b := &BuildInfo{GoVersion: runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		b.Path = info.Path
+		b.Module = info.Main.Path
+		if info.Main.Version != "(devel)" {
+			b.Version = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				b.Revision = s.Value
+			case "vcs.modified":
+				b.Modified, _ = strconv.ParseBool(s.Value)
+			case "vcs.time":
+				b.Time = s.Value
+			}
+		}
+	}
+	if BuildVersion != "" {
+		b.Version = BuildVersion
+	}
+	if BuildRevision != "" {
+		b.Revision = BuildRevision
+	}
+	if BuildTime != "" {
+		b.Time = BuildTime
+	}
+	return b
+}
+
+//currentBuildInfo reads the build info once for the detailed health of the
+//applications that do not set AppInfo.Build
+func currentBuildInfo() *BuildInfo {
+	buildInfoOnce.Do(func() {
+		buildInfo = ReadBuildInfo()
+	})
+	return buildInfo
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"os"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BuildInfo", func() {
+	AfterEach(func() {
+		BuildVersion, BuildRevision, BuildTime = "", "", ""
+	})
+
+	Describe("ReadBuildInfo", func() {
+		It("reads the Go build info", func() {
+			b := ReadBuildInfo()
+			Expect(b.GoVersion).To(Equal(runtime.Version()))
+		})
+
+		It("prefers the -ldflags variables", func() {
+			BuildVersion, BuildRevision, BuildTime = "1.2.0", "abc", "2020-01-02T15:04:05Z"
+			b := ReadBuildInfo()
+			Expect(b.Version).To(Equal("1.2.0"))
+			Expect(b.Revision).To(Equal("abc"))
+			Expect(b.Time).To(Equal("2020-01-02T15:04:05Z"))
+		})
+	})
+
+	Describe("AppInfo", func() {
+		It("fills the version and revision from the parameters", func() {
+			BuildVersion, BuildRevision = "1.2.0", "abc"
+			os.Setenv("APPLICATION_NAME", "app")
+			defer os.Unsetenv("APPLICATION_NAME")
+
+			ai := (&AppInfo{}).FillFromENV("2.0.0", "def")
+			Expect(ai.AppName).To(Equal("app"))
+			Expect(ai.Version).To(Equal("2.0.0"))
+			Expect(ai.Revision).To(Equal("def"))
+			Expect(ai.Build.Revision).To(Equal("abc"))
+		})
+
+		It("falls back to the build info", func() {
+			BuildVersion, BuildRevision = "1.2.0", "abc"
+			ai := (&AppInfo{}).FillFromENV("", "")
+			Expect(ai.Version).To(Equal("1.2.0"))
+			Expect(ai.Revision).To(Equal("abc"))
+
+			ai = (&AppInfo{Version: "3.0.0"}).FillFromBuildInfo()
+			Expect(ai.Version).To(Equal("3.0.0"))
+			Expect(ai.Revision).To(Equal("abc"))
+		})
+
+		It("shows the build info in the detailed health", func() {
+			ai := &AppInfo{Build: &BuildInfo{GoVersion: "go1.x", Module: "some/module"}}
+			Expect(NewDetailedHealth(ai, nil, "").Build).To(Equal(ai.Build))
+			Expect(NewDetailedHealth(nil, nil, "").Build.GoVersion).To(Equal(runtime.Version()))
+		})
+	})
+})
@@ -0,0 +1,151 @@
+//Package client reads the simple and detailed health of other microservices,
+//such as for dashboards and deployment gates.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+)
+
+var (
+	//DefaultWaitInterval is the first wait between the attempts of WaitForVersion
+	DefaultWaitInterval = time.Second
+	//DefaultMaxWaitInterval is the longest wait between the attempts of WaitForVersion
+	DefaultMaxWaitInterval = 30 * time.Second
+)
+
+//Client reads the health endpoints of a service
+type Client struct {
+	//BaseURL is the URL of the service without the health path, like "https://some.service"
+	BaseURL string
+	health.TransportOptions
+}
+
+//New creates a client of the service at baseURL
+func New(baseURL string, opts health.TransportOptions) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), TransportOptions: opts}
+}
+
+//SimpleHealth fetches /health of the service
+func (c *Client) SimpleHealth(ctx context.Context) (*health.SimpleHealth, error) {
+	h, err := c.fetch(ctx, "/health")
+	if err != nil {
+		return nil, err
+	}
+	return &h.SimpleHealth, nil
+}
+
+//DetailedHealth fetches the detailed health of the version group, like "v1" or
+//"/v1". An empty version group fetches /health/detailed.
+func (c *Client) DetailedHealth(ctx context.Context, versionGroup string) (*health.DetailedHealth, error) {
+	path := "/health/detailed"
+	if v := strings.Trim(versionGroup, "/"); v != "" {
+		path = "/" + v + path
+	}
+	return c.fetch(ctx, path)
+}
+
+func (c *Client) fetch(ctx context.Context, path string) (*health.DetailedHealth, error) {
+	url := c.BaseURL + path
+	resp, err := c.Get(ctx, url)
+	if err != nil {
+		return nil, errors.New("Error connecting to `" + url + "`: " + err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("Status code: %d, error checking %s", resp.StatusCode, url)
+	}
+	h, err := health.DecodeHealth(resp.Body)
+	if err != nil {
+		return nil, errors.New("Response body of `" + url + "` is not a health document: " + err.Error())
+	}
+	return h, nil
+}
+
+//VersionMatches checks if the reported version or revision is the expected one.
+//A leading "v" and surrounding spaces are ignored, so "v1.2.0" matches "1.2.0".
+func VersionMatches(h *health.SimpleHealth, expected string) bool {
+	if h == nil {
+		return false
+	}
+	expected = normalizeVersion(expected)
+	return expected != "" && (normalizeVersion(h.Version) == expected || normalizeVersion(h.Revision) == expected)
+}
+
+func normalizeVersion(v string) string {
+	v = strings.TrimSpace(v)
+	if len(v) > 1 && (v[0] == 'v' || v[0] == 'V') && v[1] >= '0' && v[1] <= '9' {
+		v = v[1:]
+	}
+	return v
+}
+
+//CheckVersion fetches the simple health and returns an error if the reported
+//version is not the expected one
+func (c *Client) CheckVersion(ctx context.Context, expected string) (*health.SimpleHealth, error) {
+	h, err := c.SimpleHealth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !VersionMatches(h, expected) {
+		return h, fmt.Errorf("Expected version `%s` but got version `%s` revision `%s`", expected, h.Version, h.Revision)
+	}
+	return h, nil
+}
+
+//WaitOptions control how WaitForVersion retries
+type WaitOptions struct {
+	//Timeout is how long to wait in total. There is no timeout other than the
+	//context's if it is 0.
+	Timeout time.Duration
+	//Interval is the first wait between the attempts and doubles after every
+	//attempt up to MaxInterval. They default to DefaultWaitInterval and
+	//DefaultMaxWaitInterval.
+	Interval    time.Duration
+	MaxInterval time.Duration
+}
+
+//WaitForVersion polls the simple health until the service reports the expected
+//version with the OK status. It returns the last error when the timeout or the
+//context expires.
+func (c *Client) WaitForVersion(ctx context.Context, expected string, opts WaitOptions) (*health.SimpleHealth, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+	maxInterval := opts.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = DefaultMaxWaitInterval
+	}
+
+	for {
+		h, err := c.CheckVersion(ctx, expected)
+		if err == nil && health.NormalizeStatus(h.Status) != health.OK {
+			err = errors.New("Status is " + h.Status)
+		}
+		if err == nil {
+			return h, nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return h, errors.New("Timed out waiting for version `" + expected + "` at " + c.BaseURL + ": " + err.Error())
+		case <-timer.C:
+		}
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
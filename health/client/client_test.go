@@ -0,0 +1,133 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/coupa/foundation-go/health/client"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		ts      *httptest.Server
+		mu      sync.Mutex
+		version string
+		status  string
+	)
+	setHealth := func(v, s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		version, status = v, s
+	}
+
+	BeforeEach(func() {
+		setHealth("1.0.0", health.OK)
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			if r.Header.Get("X-Token") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			switch r.URL.Path {
+			case "/health":
+				fmt.Fprintf(w, `{"status":"%s","version":"%s","revision":"abc"}`, status, version)
+			case "/v1/health/detailed", "/health/detailed":
+				fmt.Fprintf(w, `{"status":"%s","version":"%s","revision":"abc","name":"svc","path":"%s",
+					"dependencies":[{"name":"mysql","type":"internal","state":{"status":"OK"}}]}`, status, version, r.URL.Path)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	opts := health.TransportOptions{Headers: map[string]string{"X-Token": "secret"}}
+
+	Describe("SimpleHealth", func() {
+		It("fetches and decodes the simple health", func() {
+			h, err := client.New(ts.URL+"/", opts).SimpleHealth(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*h).To(Equal(health.SimpleHealth{Status: health.OK, Version: "1.0.0", Revision: "abc"}))
+		})
+
+		It("fails on error responses", func() {
+			_, err := client.New(ts.URL, health.TransportOptions{}).SimpleHealth(context.Background())
+			Expect(err).To(MatchError(fmt.Sprintf("Status code: 401, error checking %s/health", ts.URL)))
+		})
+	})
+
+	Describe("DetailedHealth", func() {
+		It("fetches and decodes the detailed health of the version group", func() {
+			c := client.New(ts.URL, opts)
+			h, err := c.DetailedHealth(context.Background(), "v1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(h.Name).To(Equal("svc"))
+			Expect(h.Dependencies).To(HaveLen(1))
+			Expect(h.Custom["path"]).To(Equal("/v1/health/detailed"))
+
+			h, err = c.DetailedHealth(context.Background(), "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(h.Custom["path"]).To(Equal("/health/detailed"))
+
+			_, err = c.DetailedHealth(context.Background(), "/v2")
+			Expect(err).To(MatchError(HavePrefix("Status code: 404")))
+		})
+	})
+
+	Describe("VersionMatches", func() {
+		It("compares the version or the revision", func() {
+			h := &health.SimpleHealth{Version: "v1.2.0", Revision: "abc"}
+			Expect(client.VersionMatches(h, "1.2.0")).To(BeTrue())
+			Expect(client.VersionMatches(h, " v1.2.0")).To(BeTrue())
+			Expect(client.VersionMatches(h, "abc")).To(BeTrue())
+			Expect(client.VersionMatches(h, "1.2")).To(BeFalse())
+			Expect(client.VersionMatches(h, "")).To(BeFalse())
+			Expect(client.VersionMatches(nil, "abc")).To(BeFalse())
+		})
+	})
+
+	Describe("CheckVersion", func() {
+		It("returns an error on a different version", func() {
+			c := client.New(ts.URL, opts)
+			_, err := c.CheckVersion(context.Background(), "1.0.0")
+			Expect(err).NotTo(HaveOccurred())
+
+			h, err := c.CheckVersion(context.Background(), "2.0.0")
+			Expect(err).To(MatchError("Expected version `2.0.0` but got version `1.0.0` revision `abc`"))
+			Expect(h.Version).To(Equal("1.0.0"))
+		})
+	})
+
+	Describe("WaitForVersion", func() {
+		It("waits until the service reports the version and OK", func() {
+			setHealth("2.0.0", health.CRIT)
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				setHealth("2.0.0", health.OK)
+			}()
+
+			h, err := client.New(ts.URL, opts).WaitForVersion(context.Background(), "2.0.0",
+				client.WaitOptions{Timeout: 5 * time.Second, Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(h.Status).To(Equal(health.OK))
+		})
+
+		It("times out with the last error", func() {
+			_, err := client.New(ts.URL, opts).WaitForVersion(context.Background(), "2.0.0",
+				client.WaitOptions{Timeout: 30 * time.Millisecond, Interval: time.Millisecond})
+			Expect(err).To(MatchError(fmt.Sprintf("Timed out waiting for version `2.0.0` at %s: Expected version `2.0.0` but got version `1.0.0` revision `abc`", ts.URL)))
+		})
+	})
+})
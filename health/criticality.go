@@ -0,0 +1,118 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	//CriticalityRequired dependencies affect the overall status with their own
+	//status. A dependency without criticality is required.
+	CriticalityRequired = "required"
+	//CriticalityOptional dependencies raise the overall status to WARN at most
+	CriticalityOptional = "optional"
+	//CriticalityInformational dependencies never affect the overall status
+	CriticalityInformational = "informational"
+)
+
+//CriticalityProvider can be implemented by a HealthChecker to declare its criticality
+type CriticalityProvider interface {
+	GetCriticality() string
+}
+
+//CriticalCheck wraps a HealthChecker to declare its criticality. Use Required,
+//Optional, or Informational to create it.
+type CriticalCheck struct {
+	HealthChecker
+	Criticality string
+}
+
+func (cc CriticalCheck) Check() *DependencyInfo {
+	di := cc.HealthChecker.Check()
+	if di != nil {
+		di.Criticality = cc.Criticality
+	}
+	return di
+}
+
+func (cc CriticalCheck) GetCriticality() string {
+	return cc.Criticality
+}
+
+func (cc CriticalCheck) recordTimeout(di *DependencyInfo) *DependencyInfo {
+	if tr, yes := cc.HealthChecker.(timeoutRecorder); yes {
+		return tr.recordTimeout(di)
+	}
+	return di
+}
+
+//Required declares that the check's status affects the overall status as is
+func Required(hc HealthChecker) HealthChecker {
+	return CriticalCheck{HealthChecker: hc, Criticality: CriticalityRequired}
+}
+
+//Optional declares that the check raises the overall status to WARN at most
+func Optional(hc HealthChecker) HealthChecker {
+	return CriticalCheck{HealthChecker: hc, Criticality: CriticalityOptional}
+}
+
+//Informational declares that the check never affects the overall status
+func Informational(hc HealthChecker) HealthChecker {
+	return CriticalCheck{HealthChecker: hc, Criticality: CriticalityInformational}
+}
+
+//CriticalityOf returns the criticality declared by the check, or required if
+//it does not declare one
+func CriticalityOf(hc HealthChecker) string {
+	if cp, yes := hc.(CriticalityProvider); yes && cp.GetCriticality() != "" {
+		return cp.GetCriticality()
+	}
+	return CriticalityRequired
+}
+
+//EffectiveStatus is the status of the dependency after applying its criticality
+func EffectiveStatus(d *DependencyInfo) string {
+	switch d.Criticality {
+	case CriticalityOptional:
+		if IsMoreCritical(d.State.Status, WARN) {
+			return WARN
+		}
+	case CriticalityInformational:
+		return OK
+	}
+	return d.State.Status
+}
+
+//AggregateStatus returns the overall status of the dependencies, which is the
+//most critical effective status, and the reason why it is chosen.
+func AggregateStatus(deps []*DependencyInfo) (string, string) {
+	status := OK
+	for _, d := range deps {
+		if d != nil && IsMoreCritical(EffectiveStatus(d), status) {
+			status = EffectiveStatus(d)
+		}
+	}
+	if status == OK {
+		if len(deps) == 0 {
+			return status, "OK: there is no dependency"
+		}
+		return status, "OK: no dependency affects the status"
+	}
+
+	var causes []string
+	for _, d := range deps {
+		if d == nil || EffectiveStatus(d) != status {
+			continue
+		}
+		criticality := d.Criticality
+		if criticality == "" {
+			criticality = CriticalityRequired
+		}
+		cause := fmt.Sprintf("%s dependency `%s` is %s", criticality, d.Name, d.State.Status)
+		if d.State.Status != status {
+			cause += " and counts as " + status
+		}
+		causes = append(causes, cause)
+	}
+	return status, status + ": " + strings.Join(causes, "; ")
+}
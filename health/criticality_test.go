@@ -0,0 +1,53 @@
+package health
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Criticality", func() {
+	dep := func(name, status, criticality string) *DependencyInfo {
+		return &DependencyInfo{Name: name, Criticality: criticality, State: DependencyState{Status: status}}
+	}
+
+	Describe("CriticalCheck", func() {
+		It("sets the criticality of the result", func() {
+			hc := Optional(stubCheck{name: "analytics", status: CRIT})
+			Expect(hc.GetName()).To(Equal("analytics"))
+			Expect(hc.Check().Criticality).To(Equal(CriticalityOptional))
+			Expect(CriticalityOf(hc)).To(Equal(CriticalityOptional))
+			Expect(CriticalityOf(Informational(hc))).To(Equal(CriticalityInformational))
+			Expect(CriticalityOf(stubCheck{})).To(Equal(CriticalityRequired))
+		})
+	})
+
+	Describe("EffectiveStatus", func() {
+		It("caps optional at WARN and ignores informational", func() {
+			Expect(EffectiveStatus(dep("a", CRIT, ""))).To(Equal(CRIT))
+			Expect(EffectiveStatus(dep("a", CRIT, CriticalityRequired))).To(Equal(CRIT))
+			Expect(EffectiveStatus(dep("a", CRIT, CriticalityOptional))).To(Equal(WARN))
+			Expect(EffectiveStatus(dep("a", OK, CriticalityOptional))).To(Equal(OK))
+			Expect(EffectiveStatus(dep("a", CRIT, CriticalityInformational))).To(Equal(OK))
+		})
+	})
+
+	Describe("AggregateStatus", func() {
+		It("returns the most critical effective status and the reason", func() {
+			status, reason := AggregateStatus(nil)
+			Expect(status).To(Equal(OK))
+			Expect(reason).To(Equal("OK: there is no dependency"))
+
+			status, reason = AggregateStatus([]*DependencyInfo{dep("a", OK, ""), dep("b", CRIT, CriticalityInformational)})
+			Expect(status).To(Equal(OK))
+			Expect(reason).To(Equal("OK: no dependency affects the status"))
+
+			status, reason = AggregateStatus([]*DependencyInfo{dep("a", OK, ""), dep("b", CRIT, CriticalityOptional), dep("c", WARN, "")})
+			Expect(status).To(Equal(WARN))
+			Expect(reason).To(Equal("WARN: optional dependency `b` is CRIT and counts as WARN; required dependency `c` is WARN"))
+
+			status, reason = AggregateStatus([]*DependencyInfo{dep("a", CRIT, ""), dep("b", CRIT, CriticalityOptional)})
+			Expect(status).To(Equal(CRIT))
+			Expect(reason).To(Equal("CRIT: required dependency `a` is CRIT"))
+		})
+	})
+})
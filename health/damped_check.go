@@ -0,0 +1,180 @@
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+//DampingOptions control when a DampedCheck changes its status
+type DampingOptions struct {
+	//FailureThreshold is the number of consecutive non-OK results needed to change
+	//to the most critical status among them. It defaults to 1.
+	FailureThreshold int
+	//SuccessThreshold is the number of consecutive results with a less critical
+	//status needed to change to that status. It defaults to 1.
+	SuccessThreshold int
+	//WindowSize is the number of recent results in the sliding window. The window
+	//is not used if it is <= 0.
+	WindowSize int
+	//FailureRatio changes the status to the most critical status in the window
+	//when the ratio of non-OK results in the full window reaches it, such as 0.5,
+	//even without enough consecutive failures. The status does not change to a
+	//less critical one while the ratio of the results in the window reaches it.
+	//It is ignored if it is <= 0.
+	FailureRatio float64
+}
+
+//DampedCheck wraps a HealthChecker so that a single failure or success does not
+//flip its status. It must be created with NewDampedCheck and reused across
+//health checks since it keeps the state of the previous results. A panic of the
+//wrapped check and a timeout reported by the CheckExecutor are damped as CRIT
+//results.
+type DampedCheck struct {
+	HealthChecker
+	Options DampingOptions
+
+	mu         sync.Mutex
+	status     string
+	lastChange time.Time
+	candidate  string
+	count      int
+	failures   int
+	worst      string
+	window     []string
+	timeouts   int
+}
+
+//NewDampedCheck wraps the check with flap damping
+func NewDampedCheck(hc HealthChecker, opts DampingOptions) *DampedCheck {
+	return &DampedCheck{HealthChecker: hc, Options: opts}
+}
+
+//Check runs the wrapped check and returns its result with the damped status.
+//The raw state is in RawState.
+func (dc *DampedCheck) Check() *DependencyInfo {
+	dc.mu.Lock()
+	timeouts := dc.timeouts
+	dc.mu.Unlock()
+
+	di := safeCheck(dc.HealthChecker)
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	//The result is late if the executor reported a timeout in the meantime, which
+	//was recorded instead
+	return dc.damp(di, dc.timeouts == timeouts)
+}
+
+func (dc *DampedCheck) GetCriticality() string {
+	return CriticalityOf(dc.HealthChecker)
+}
+
+//recordTimeout damps the result that the CheckExecutor reports for the check when
+//it does not finish in time
+func (dc *DampedCheck) recordTimeout(di *DependencyInfo) *DependencyInfo {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.timeouts++
+	return dc.damp(di, true)
+}
+
+//damp sets the damped status on the result, recording the raw status if record
+//is true. The lock must be held.
+func (dc *DampedCheck) damp(di *DependencyInfo, record bool) *DependencyInfo {
+	raw := di.State
+	if record || dc.status == "" {
+		dc.update(raw.Status, time.Now())
+	}
+	lastChange := dc.lastChange
+	di.State.Status = dc.status
+	di.RawState = &raw
+	di.LastStatusChange = &lastChange
+	return di
+}
+
+//update records the raw status and updates the damped status. The non-OK results
+//count as one failure streak even if they alternate between WARN and CRIT, and
+//the streak changes the status to its most critical status. A change by the
+//consecutive results is a candidate that the window can overrule, so that the
+//status changes at most once per result.
+func (dc *DampedCheck) update(raw string, now time.Time) {
+	if dc.Options.WindowSize > 0 {
+		dc.window = append(dc.window, raw)
+		if len(dc.window) > dc.Options.WindowSize {
+			dc.window = dc.window[len(dc.window)-dc.Options.WindowSize:]
+		}
+	}
+	if raw == OK {
+		dc.failures, dc.worst = 0, ""
+	} else {
+		dc.failures++
+		if dc.worst == "" || IsMoreCritical(raw, dc.worst) {
+			dc.worst = raw
+		}
+	}
+	if dc.status == "" {
+		dc.setStatus(raw, now)
+		return
+	}
+
+	status := dc.status
+	if raw != OK && dc.failures >= dc.Options.FailureThreshold && IsMoreCritical(dc.worst, dc.status) {
+		status = dc.worst
+	} else if IsMoreCritical(dc.status, raw) {
+		if raw == dc.candidate {
+			dc.count++
+		} else {
+			dc.candidate, dc.count = raw, 1
+		}
+		if dc.count >= dc.Options.SuccessThreshold {
+			status = raw
+		}
+	} else {
+		dc.candidate, dc.count = "", 0
+	}
+
+	if worst, failing, full := dc.windowFailure(); failing {
+		//A recovery must also clear the failure ratio of the window
+		if IsMoreCritical(dc.status, status) {
+			status = dc.status
+		}
+		if full && IsMoreCritical(worst, status) {
+			status = worst
+		}
+	}
+	if status != dc.status {
+		if status != OK && IsMoreCritical(dc.status, status) {
+			//The failure streak continues from the recovered status
+			dc.worst = status
+		}
+		dc.setStatus(status, now)
+	}
+}
+
+//windowFailure returns the most critical status in the window, whether the ratio
+//of non-OK results in the window reaches FailureRatio, and whether the window is
+//full
+func (dc *DampedCheck) windowFailure() (string, bool, bool) {
+	if dc.Options.FailureRatio <= 0 || dc.Options.WindowSize <= 0 || len(dc.window) == 0 {
+		return "", false, false
+	}
+	worst := OK
+	failures := 0
+	for _, s := range dc.window {
+		if s != OK {
+			failures++
+		}
+		if IsMoreCritical(s, worst) {
+			worst = s
+		}
+	}
+	return worst, float64(failures)/float64(len(dc.window)) >= dc.Options.FailureRatio, len(dc.window) >= dc.Options.WindowSize
+}
+
+func (dc *DampedCheck) setStatus(status string, now time.Time) {
+	if status != dc.status {
+		dc.lastChange = now
+	}
+	dc.status = status
+	dc.candidate, dc.count = "", 0
+}
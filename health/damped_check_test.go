@@ -0,0 +1,144 @@
+package health
+
+import (
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DampedCheck", func() {
+	//run feeds the statuses to the damped check and returns the damped statuses
+	run := func(dc *DampedCheck, sc *stubCheck, statuses ...string) []string {
+		var damped []string
+		for _, s := range statuses {
+			sc.status = s
+			damped = append(damped, dc.Check().State.Status)
+		}
+		return damped
+	}
+
+	It("changes the status after consecutive results", func() {
+		sc := &stubCheck{name: "flaky"}
+		dc := NewDampedCheck(sc, DampingOptions{FailureThreshold: 3, SuccessThreshold: 2})
+		Expect(dc.GetName()).To(Equal("flaky"))
+
+		Expect(run(dc, sc, OK, CRIT, CRIT, OK, CRIT, CRIT, CRIT, OK, CRIT, OK, OK)).To(Equal(
+			[]string{OK, OK, OK, OK, OK, OK, CRIT, CRIT, CRIT, CRIT, OK}))
+	})
+
+	It("reports the raw state and the last status change", func() {
+		sc := &stubCheck{name: "flaky"}
+		dc := NewDampedCheck(sc, DampingOptions{FailureThreshold: 2})
+
+		sc.status = OK
+		d := dc.Check()
+		Expect(d.LastStatusChange).NotTo(BeNil())
+		changed := *d.LastStatusChange
+
+		sc.status = WARN
+		d = dc.Check()
+		Expect(d.State.Status).To(Equal(OK))
+		Expect(d.State.Details).To(Equal("stub WARN"))
+		Expect(d.RawState.Status).To(Equal(WARN))
+		Expect(*d.LastStatusChange).To(Equal(changed))
+
+		d = dc.Check()
+		Expect(d.State.Status).To(Equal(WARN))
+		Expect(d.LastStatusChange.After(changed)).To(BeTrue())
+	})
+
+	It("changes the status when the failure ratio of the window is reached", func() {
+		sc := &stubCheck{name: "flaky"}
+		dc := NewDampedCheck(sc, DampingOptions{FailureThreshold: 10, SuccessThreshold: 2, WindowSize: 4, FailureRatio: 0.5})
+
+		Expect(run(dc, sc, OK, WARN, OK, CRIT, OK, OK)).To(Equal(
+			[]string{OK, OK, OK, CRIT, CRIT, OK}))
+	})
+
+	DescribeTable("damps mixed sequences of results without flapping",
+		func(opts DampingOptions, statuses, expected []string) {
+			sc := &stubCheck{name: "flaky"}
+			Expect(run(NewDampedCheck(sc, opts), sc, statuses...)).To(Equal(expected))
+		},
+		Entry("window without thresholds",
+			DampingOptions{WindowSize: 4, FailureRatio: 0.5},
+			[]string{CRIT, CRIT, OK, OK, OK},
+			[]string{CRIT, CRIT, CRIT, CRIT, OK}),
+		Entry("window recovering to a less critical status",
+			DampingOptions{WindowSize: 4, FailureRatio: 0.5},
+			[]string{OK, CRIT, CRIT, OK, WARN, OK, OK, OK},
+			[]string{OK, CRIT, CRIT, CRIT, CRIT, CRIT, OK, OK}),
+		Entry("window with thresholds",
+			DampingOptions{FailureThreshold: 2, SuccessThreshold: 2, WindowSize: 4, FailureRatio: 0.75},
+			[]string{OK, CRIT, OK, CRIT, CRIT, OK, OK, CRIT, OK, OK},
+			[]string{OK, OK, OK, OK, CRIT, CRIT, OK, OK, OK, OK}),
+		Entry("failures alternating between WARN and CRIT",
+			DampingOptions{FailureThreshold: 3, SuccessThreshold: 2},
+			[]string{OK, WARN, CRIT, WARN, CRIT, WARN, OK, OK},
+			[]string{OK, OK, OK, CRIT, CRIT, CRIT, CRIT, OK}),
+		Entry("recovering to WARN in a failure streak",
+			DampingOptions{FailureThreshold: 2, SuccessThreshold: 2},
+			[]string{OK, CRIT, CRIT, WARN, WARN, WARN, CRIT, CRIT},
+			[]string{OK, OK, CRIT, CRIT, WARN, WARN, CRIT, CRIT}),
+		Entry("thresholds only",
+			DampingOptions{FailureThreshold: 2, SuccessThreshold: 3},
+			[]string{OK, CRIT, OK, CRIT, CRIT, OK, OK, CRIT, OK, OK, OK},
+			[]string{OK, OK, OK, OK, CRIT, CRIT, CRIT, CRIT, CRIT, CRIT, OK}),
+	)
+
+	It("changes the last status change only when the status changes", func() {
+		sc := &stubCheck{name: "flaky"}
+		dc := NewDampedCheck(sc, DampingOptions{WindowSize: 4, FailureRatio: 0.5})
+		sc.status = CRIT
+		changed := *dc.Check().LastStatusChange
+		for _, s := range []string{CRIT, OK, OK} {
+			sc.status = s
+			d := dc.Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+			Expect(*d.LastStatusChange).To(Equal(changed))
+		}
+	})
+
+	It("damps the panics and the timeouts reported by the executor", func() {
+		release := make(chan struct{})
+		var mode atomic.Value
+		mode.Store("")
+		check := stubCheck{name: "slow", check: func() *DependencyInfo {
+			switch mode.Load() {
+			case "panic":
+				panic("boom")
+			case "hang":
+				<-release
+			}
+			return &DependencyInfo{Name: "slow", State: DependencyState{Status: OK}}
+		}}
+		dc := NewDampedCheck(check, DampingOptions{FailureThreshold: 2})
+		executor := CheckExecutor{Timeout: 50 * time.Millisecond}
+
+		Expect(executor.Run([]HealthChecker{dc})[0].State.Status).To(Equal(OK))
+		mode.Store("hang")
+		results := executor.Run([]HealthChecker{Optional(dc)})
+		Expect(results[0].State.Status).To(Equal(OK))
+		Expect(results[0].RawState.Status).To(Equal(CRIT))
+		Expect(results[0].RawState.Details).To(HavePrefix("Health check timed out"))
+		Expect(results[0].Criticality).To(Equal(CriticalityOptional))
+
+		//The late OK result of the timed out check is not recorded, so the panic is
+		//the second consecutive failure
+		mode.Store("panic")
+		close(release)
+		time.Sleep(20 * time.Millisecond)
+		d := dc.Check()
+		Expect(d.State.Status).To(Equal(CRIT))
+		Expect(d.RawState.Details).To(Equal("Health check panicked: boom"))
+	})
+
+	It("keeps the criticality of the wrapped check", func() {
+		dc := NewDampedCheck(Optional(stubCheck{name: "a", status: CRIT}), DampingOptions{})
+		Expect(CriticalityOf(dc)).To(Equal(CriticalityOptional))
+		Expect(dc.Check().Criticality).To(Equal(CriticalityOptional))
+	})
+})
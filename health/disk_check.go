@@ -0,0 +1,74 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+//DiskCheck checks the free space and free inodes of the file system that Path
+//is on. It is CRIT when the file system cannot be read.
+type DiskCheck struct {
+	Name string
+	Type string
+	Path string
+	//FreeSpace sets WARN or CRIT when the ratio of available space to the total
+	//space drops to the levels, such as {Warn: 0.2, Crit: 0.1}
+	FreeSpace Thresholds
+	//FreeBytes sets WARN or CRIT when the available space in bytes drops to the levels
+	FreeBytes Thresholds
+	//FreeInodes sets WARN or CRIT when the ratio of free inodes to the total inodes
+	//drops to the levels. It is ignored by file systems that do not report inodes.
+	FreeInodes Thresholds
+}
+
+//diskStats is the usage of a file system
+type diskStats struct {
+	total      uint64
+	free       uint64
+	inodes     uint64
+	freeInodes uint64
+}
+
+func (dc DiskCheck) Check() *DependencyInfo {
+	state := DependencyState{Status: OK}
+
+	sTime := time.Now()
+	stats, err := diskUsage(dc.Path)
+	t := time.Since(sTime).Seconds()
+
+	if err != nil {
+		err = errors.New("Error reading the file system of `" + dc.Path + "`: " + err.Error())
+	} else if stats.total == 0 {
+		err = errors.New("The file system of `" + dc.Path + "` reports no space")
+	} else {
+		freeRatio := float64(stats.free) / float64(stats.total)
+		raiseStatus(&state, dc.FreeSpace.statusBelow(freeRatio))
+		raiseStatus(&state, dc.FreeBytes.statusBelow(float64(stats.free)))
+		state.Details = fmt.Sprintf("free=%d total=%d free_ratio=%.4f", stats.free, stats.total, freeRatio)
+		if stats.inodes > 0 {
+			inodeRatio := float64(stats.freeInodes) / float64(stats.inodes)
+			raiseStatus(&state, dc.FreeInodes.statusBelow(inodeRatio))
+			state.Details += fmt.Sprintf(" free_inodes=%d inodes=%d free_inodes_ratio=%.4f", stats.freeInodes, stats.inodes, inodeRatio)
+		}
+	}
+
+	if err != nil {
+		state.Status = CRIT
+		state.Details = err.Error()
+	}
+	return &DependencyInfo{
+		Name:         dc.Name,
+		Type:         dc.Type,
+		State:        state,
+		ResponseTime: t,
+	}
+}
+
+func (dc DiskCheck) GetName() string {
+	return dc.Name
+}
+
+func (dc DiskCheck) GetType() string {
+	return dc.Type
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package health
+
+import (
+	"errors"
+	"runtime"
+)
+
+func diskUsage(path string) (*diskStats, error) {
+	return nil, errors.New("disk usage is not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,33 @@
+package health
+
+import (
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Disk", func() {
+	Describe("Check", func() {
+		if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+			It("reports the free space and applies the thresholds", func() {
+				d := DiskCheck{Name: "test", Path: "."}.Check()
+				Expect(d.Name).To(Equal("test"))
+				Expect(d.State.Status).To(Equal(OK))
+				Expect(d.State.Details).To(MatchRegexp(`^free=\d+ total=\d+ free_ratio=`))
+
+				d = DiskCheck{Name: "test", Path: ".", FreeSpace: Thresholds{Warn: 1}}.Check()
+				Expect(d.State.Status).To(Equal(WARN))
+
+				d = DiskCheck{Name: "test", Path: ".", FreeSpace: Thresholds{Warn: 1, Crit: 1}}.Check()
+				Expect(d.State.Status).To(Equal(CRIT))
+			})
+		}
+
+		It("sets CRIT when the path does not exist", func() {
+			d := DiskCheck{Name: "test", Path: "/does/not/exist"}.Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+			Expect(d.State.Details).To(HavePrefix("Error reading the file system of `/does/not/exist`: "))
+		})
+	})
+})
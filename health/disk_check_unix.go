@@ -0,0 +1,21 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package health
+
+import (
+	"syscall"
+)
+
+func diskUsage(path string) (*diskStats, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return nil, err
+	}
+	return &diskStats{
+		total:      uint64(fs.Blocks) * uint64(fs.Bsize),
+		free:       uint64(fs.Bavail) * uint64(fs.Bsize),
+		inodes:     uint64(fs.Files),
+		freeInodes: uint64(fs.Ffree),
+	}, nil
+}
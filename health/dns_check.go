@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+//DNSCheck checks that Host resolves to at least one address. It is CRIT when
+//the resolution fails or returns no address.
+type DNSCheck struct {
+	Name string
+	Type string
+	Host string
+	//Resolver defaults to net.DefaultResolver
+	Resolver *net.Resolver
+	//Timeout defaults to DefaultDialTimeout
+	Timeout time.Duration
+	//ResponseTime sets WARN or CRIT when the resolution takes longer
+	ResponseTime DurationThresholds
+}
+
+func (dc DNSCheck) Check() *DependencyInfo {
+	var err error
+	var addrs []string
+	state := DependencyState{Status: OK}
+	resolver := dc.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	timeout := dc.Timeout
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	sTime := time.Now()
+	addrs, err = resolver.LookupHost(ctx, dc.Host)
+	elapsed := time.Since(sTime)
+
+	if err != nil {
+		err = errors.New("Error resolving `" + dc.Host + "`: " + err.Error())
+	} else if len(addrs) == 0 {
+		err = errors.New("No address is found for `" + dc.Host + "`")
+	} else {
+		state.Details = fmt.Sprintf("Resolved to %s", strings.Join(addrs, ", "))
+		raiseStatus(&state, dc.ResponseTime.statusAbove(elapsed))
+		if state.Status != OK {
+			state.Details += " in " + elapsed.String()
+		}
+	}
+
+	if err != nil {
+		state.Status = CRIT
+		state.Details = err.Error()
+	}
+	return &DependencyInfo{
+		Name:         dc.Name,
+		Type:         dc.Type,
+		State:        state,
+		ResponseTime: elapsed.Seconds(),
+	}
+}
+
+func (dc DNSCheck) GetName() string {
+	return dc.Name
+}
+
+func (dc DNSCheck) GetType() string {
+	return dc.Type
+}
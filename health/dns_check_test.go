@@ -0,0 +1,25 @@
+package health
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DNS", func() {
+	Describe("Check", func() {
+		It("is OK when the host resolves", func() {
+			d := DNSCheck{Name: "test", Type: TypeInternal, Host: "localhost"}.Check()
+			Expect(d.Name).To(Equal("test"))
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.State.Details).To(HavePrefix("Resolved to "))
+		})
+
+		It("sets CRIT when the host does not resolve", func() {
+			d := DNSCheck{Name: "test", Host: "foundation-go.invalid", Timeout: time.Second}.Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+			Expect(d.State.Details).To(HavePrefix("Error resolving `foundation-go.invalid`: "))
+		})
+	})
+})
@@ -0,0 +1,121 @@
+package health
+
+import (
+	"fmt"
+	"time"
+)
+
+//CheckExecutor runs health checks concurrently. The checks are tracked by their
+//index, so a check can be of any type, including a struct with maps or slices,
+//and the same check can be run more than once.
+type CheckExecutor struct {
+	//Timeout is how long Run waits for the checks. The checks that have not
+	//finished by then are reported as CRIT. Run waits for all the checks if it
+	//is <= 0.
+	Timeout time.Duration
+	//MaxParallel limits the number of checks running at the same time. There is
+	//no limit if it is <= 0.
+	MaxParallel int
+}
+
+//timeoutRecorder is a check that records the results that the executor reports
+//when it does not finish in time, like a DampedCheck
+type timeoutRecorder interface {
+	recordTimeout(di *DependencyInfo) *DependencyInfo
+}
+
+//checkResult is the result of the check at the index
+type checkResult struct {
+	index int
+	di    *DependencyInfo
+}
+
+//Run runs the checks and returns their results in the same order as the checks.
+//A check that panics, returns nil, or does not finish within Timeout is reported
+//as CRIT. The criticality declared by a check is set on its result.
+func (ce CheckExecutor) Run(checks []HealthChecker) []*DependencyInfo {
+	num := len(checks)
+	if num == 0 {
+		return nil
+	}
+	//The buffer is large enough for the checks that finish after the timeout
+	buffer := make(chan checkResult, num)
+	done := make(chan struct{})
+	defer close(done)
+
+	var slots chan struct{}
+	if ce.MaxParallel > 0 {
+		slots = make(chan struct{}, ce.MaxParallel)
+	}
+	for i, hc := range checks {
+		go func(i int, hc HealthChecker) {
+			if slots != nil {
+				select {
+				case slots <- struct{}{}:
+					defer func() { <-slots }()
+				case <-done:
+					return
+				}
+			}
+			buffer <- checkResult{index: i, di: safeCheck(hc)}
+		}(i, hc)
+	}
+
+	results := make([]*DependencyInfo, num)
+	//A nil channel never fires, so there is no timeout
+	var timeout <-chan time.Time
+	if ce.Timeout > 0 {
+		timer := time.NewTimer(ce.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+Label:
+	for received := 0; received < num; received++ {
+		select {
+		case r := <-buffer:
+			results[r.index] = r.di
+		case <-timeout:
+			break Label
+		}
+	}
+
+	for i, di := range results {
+		//The checks without a result are timed out
+		if di == nil {
+			results[i] = failedResult(checks[i], fmt.Sprintf("Health check timed out after %f seconds", ce.Timeout.Seconds()), ce.Timeout.Seconds())
+			if tr, yes := checks[i].(timeoutRecorder); yes {
+				results[i] = tr.recordTimeout(results[i])
+			}
+		}
+		if _, yes := checks[i].(CriticalityProvider); yes {
+			results[i].Criticality = CriticalityOf(checks[i])
+		}
+	}
+	return results
+}
+
+//safeCheck runs the check and reports a panic or a nil result as CRIT
+func safeCheck(hc HealthChecker) (di *DependencyInfo) {
+	sTime := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			di = failedResult(hc, fmt.Sprintf("Health check panicked: %v", r), time.Since(sTime).Seconds())
+		}
+	}()
+	if di = hc.Check(); di == nil {
+		di = failedResult(hc, "Health check returned no result", time.Since(sTime).Seconds())
+	}
+	return di
+}
+
+func failedResult(hc HealthChecker, details string, responseTime float64) *DependencyInfo {
+	return &DependencyInfo{
+		Name:         hc.GetName(),
+		Type:         hc.GetType(),
+		ResponseTime: responseTime,
+		State: DependencyState{
+			Status:  CRIT,
+			Details: details,
+		},
+	}
+}
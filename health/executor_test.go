@@ -0,0 +1,125 @@
+package health
+
+import (
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CheckExecutor", func() {
+	Describe("Run", func() {
+		It("returns the results in the order of the checks", func() {
+			checks := []HealthChecker{
+				stubCheck{name: "slow", check: func() *DependencyInfo {
+					time.Sleep(50 * time.Millisecond)
+					return &DependencyInfo{Name: "slow", State: DependencyState{Status: OK}}
+				}},
+				stubCheck{name: "a", status: WARN},
+				stubCheck{name: "a", status: WARN},
+			}
+			results := CheckExecutor{Timeout: time.Second}.Run(checks)
+			Expect(results).To(HaveLen(3))
+			Expect(results[0].Name).To(Equal("slow"))
+			Expect(results[1].Name).To(Equal("a"))
+			Expect(results[2].Name).To(Equal("a"))
+
+			Expect(CheckExecutor{Timeout: time.Second}.Run(nil)).To(BeNil())
+		})
+
+		It("reports a panic or a nil result as CRIT", func() {
+			checks := []HealthChecker{
+				Optional(stubCheck{name: "panicky", check: func() *DependencyInfo { panic("boom") }}),
+				stubCheck{name: "empty", check: func() *DependencyInfo { return nil }},
+			}
+			results := CheckExecutor{Timeout: time.Second}.Run(checks)
+			Expect(results[0].Name).To(Equal("panicky"))
+			Expect(results[0].Type).To(Equal(TypeService))
+			Expect(results[0].Criticality).To(Equal(CriticalityOptional))
+			Expect(results[0].State).To(Equal(DependencyState{Status: CRIT, Details: "Health check panicked: boom"}))
+			Expect(results[1].State).To(Equal(DependencyState{Status: CRIT, Details: "Health check returned no result"}))
+		})
+
+		It("sets the criticality declared by the checks on their results", func() {
+			checks := []HealthChecker{
+				providerCheck{stubCheck: stubCheck{name: "analytics", status: CRIT}, criticality: CriticalityOptional},
+				providerCheck{stubCheck: stubCheck{name: "cache", status: CRIT}},
+				stubCheck{name: "db", status: OK},
+			}
+			results := CheckExecutor{Timeout: time.Second}.Run(checks)
+			Expect(results[0].Criticality).To(Equal(CriticalityOptional))
+			Expect(results[1].Criticality).To(Equal(CriticalityRequired))
+			Expect(results[2].Criticality).To(BeEmpty())
+
+			status, reason := AggregateStatus(results[:1])
+			Expect(status).To(Equal(WARN))
+			Expect(reason).To(Equal("WARN: optional dependency `analytics` is CRIT and counts as WARN"))
+		})
+
+		It("reports the checks that do not finish within Timeout as CRIT", func() {
+			checks := []HealthChecker{
+				stubCheck{name: "fast", status: OK},
+				stubCheck{name: "slow", check: func() *DependencyInfo {
+					time.Sleep(time.Second)
+					return &DependencyInfo{}
+				}},
+			}
+			results := CheckExecutor{Timeout: 50 * time.Millisecond}.Run(checks)
+			Expect(results[0].State.Status).To(Equal(OK))
+			Expect(results[1].Name).To(Equal("slow"))
+			Expect(results[1].ResponseTime).To(Equal(0.05))
+			Expect(results[1].State.Status).To(Equal(CRIT))
+			Expect(results[1].State.Details).To(HavePrefix("Health check timed out after 0.05"))
+		})
+
+		It("waits for all the checks without Timeout", func() {
+			checks := []HealthChecker{
+				stubCheck{name: "fast", status: OK},
+				stubCheck{name: "slow", check: func() *DependencyInfo {
+					time.Sleep(50 * time.Millisecond)
+					return &DependencyInfo{Name: "slow", State: DependencyState{Status: OK}}
+				}},
+			}
+			results := CheckExecutor{}.Run(checks)
+			Expect(results[0].State.Status).To(Equal(OK))
+			Expect(results[1].State.Status).To(Equal(OK))
+		})
+
+		It("limits the number of checks running at the same time", func() {
+			var running, maxRunning int32
+			check := func() *DependencyInfo {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&maxRunning)
+					if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return &DependencyInfo{State: DependencyState{Status: OK}}
+			}
+			var checks []HealthChecker
+			for i := 0; i < 6; i++ {
+				checks = append(checks, stubCheck{name: "check", check: check})
+			}
+			results := CheckExecutor{Timeout: time.Second, MaxParallel: 2}.Run(checks)
+			Expect(results).To(HaveLen(6))
+			for _, di := range results {
+				Expect(di.State.Status).To(Equal(OK))
+			}
+			Expect(atomic.LoadInt32(&maxRunning)).To(Equal(int32(2)))
+		})
+	})
+})
+
+//providerCheck declares its criticality without setting it on its results
+type providerCheck struct {
+	stubCheck
+	criticality string
+}
+
+func (pc providerCheck) GetCriticality() string {
+	return pc.criticality
+}
@@ -0,0 +1,55 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+//FileFreshnessCheck checks that the file at Path was modified recently, such as
+//a cache file that is refreshed periodically. It is CRIT when the file does not
+//exist.
+type FileFreshnessCheck struct {
+	Name string
+	Type string
+	Path string
+	//MaxAge sets WARN or CRIT when the time since the last modification reaches
+	//the durations, such as {Warn: 10 * time.Minute, Crit: time.Hour}
+	MaxAge DurationThresholds
+}
+
+func (fc FileFreshnessCheck) Check() *DependencyInfo {
+	state := DependencyState{Status: OK}
+
+	sTime := time.Now()
+	info, err := os.Stat(fc.Path)
+	t := time.Since(sTime).Seconds()
+
+	if err != nil {
+		err = errors.New("Error reading `" + fc.Path + "`: " + err.Error())
+	} else {
+		age := time.Since(info.ModTime())
+		raiseStatus(&state, fc.MaxAge.statusAbove(age))
+		state.Details = fmt.Sprintf("Modified at %s, %s ago", info.ModTime().UTC().Format(time.RFC3339), age.Truncate(time.Second))
+	}
+
+	if err != nil {
+		state.Status = CRIT
+		state.Details = err.Error()
+	}
+	return &DependencyInfo{
+		Name:         fc.Name,
+		Type:         fc.Type,
+		State:        state,
+		ResponseTime: t,
+	}
+}
+
+func (fc FileFreshnessCheck) GetName() string {
+	return fc.Name
+}
+
+func (fc FileFreshnessCheck) GetType() string {
+	return fc.Type
+}
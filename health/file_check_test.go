@@ -0,0 +1,41 @@
+package health
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileFreshness", func() {
+	Describe("Check", func() {
+		It("applies the thresholds to the age of the file", func() {
+			f, err := ioutil.TempFile("", "freshness")
+			Expect(err).NotTo(HaveOccurred())
+			f.Close()
+			defer os.Remove(f.Name())
+
+			check := FileFreshnessCheck{Name: "test", Path: f.Name(), MaxAge: DurationThresholds{Warn: 10 * time.Minute, Crit: time.Hour}}
+			d := check.Check()
+			Expect(d.Name).To(Equal("test"))
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.State.Details).To(HavePrefix("Modified at "))
+
+			old := time.Now().Add(-30 * time.Minute)
+			Expect(os.Chtimes(f.Name(), old, old)).To(Succeed())
+			Expect(check.Check().State.Status).To(Equal(WARN))
+
+			old = time.Now().Add(-2 * time.Hour)
+			Expect(os.Chtimes(f.Name(), old, old)).To(Succeed())
+			Expect(check.Check().State.Status).To(Equal(CRIT))
+		})
+
+		It("sets CRIT when the file does not exist", func() {
+			d := FileFreshnessCheck{Name: "test", Path: "/does/not/exist"}.Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+			Expect(d.State.Details).To(HavePrefix("Error reading `/does/not/exist`: "))
+		})
+	})
+})
@@ -0,0 +1,42 @@
+package health
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+//GoroutineCheck checks the number of goroutines of the current process, which
+//helps detect goroutine leaks
+type GoroutineCheck struct {
+	Name string
+	Type string
+	//MaxGoroutines sets WARN or CRIT when the number of goroutines reaches the levels
+	MaxGoroutines Thresholds
+}
+
+func (gc GoroutineCheck) Check() *DependencyInfo {
+	state := DependencyState{Status: OK}
+
+	sTime := time.Now()
+	num := runtime.NumGoroutine()
+	t := time.Since(sTime).Seconds()
+
+	raiseStatus(&state, gc.MaxGoroutines.statusAbove(float64(num)))
+	state.Details = fmt.Sprintf("goroutines=%d", num)
+
+	return &DependencyInfo{
+		Name:         gc.Name,
+		Type:         gc.Type,
+		State:        state,
+		ResponseTime: t,
+	}
+}
+
+func (gc GoroutineCheck) GetName() string {
+	return gc.Name
+}
+
+func (gc GoroutineCheck) GetType() string {
+	return gc.Type
+}
@@ -0,0 +1,20 @@
+package health
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Goroutine", func() {
+	Describe("Check", func() {
+		It("reports the number of goroutines and applies the thresholds", func() {
+			d := GoroutineCheck{Name: "test"}.Check()
+			Expect(d.Name).To(Equal("test"))
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.State.Details).To(MatchRegexp(`^goroutines=\d+$`))
+
+			d = GoroutineCheck{Name: "test", MaxGoroutines: Thresholds{Warn: 1, Crit: 1000000}}.Check()
+			Expect(d.State.Status).To(Equal(WARN))
+		})
+	})
+})
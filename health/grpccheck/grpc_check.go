@@ -0,0 +1,89 @@
+//Package grpccheck checks downstream gRPC services. It is separate from the health
+//package so that only the users of the check depend on gRPC.
+package grpccheck
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+//GRPCCheck checks a downstream gRPC service with the standard grpc.health.v1.Health
+//service. SERVING is OK, and NOT_SERVING or any error is CRIT.
+type GRPCCheck struct {
+	Name string
+	Type string
+	//Conn is the connection to the downstream service. If it is nil, a connection
+	//to Address is made for every check and closed afterwards.
+	Conn *grpc.ClientConn
+	//Address is like "some.host:50051". It is used only when Conn is nil.
+	Address string
+	//Credentials is used to connect to Address. The connection is insecure if it is nil.
+	Credentials credentials.TransportCredentials
+	//Service is the service name to check. The empty name checks the whole server.
+	Service string
+	//Timeout defaults to health.DefaultDialTimeout
+	Timeout time.Duration
+}
+
+func (gc GRPCCheck) Check() *health.DependencyInfo {
+	var err error
+	state := health.DependencyState{Status: health.OK}
+	timeout := gc.Timeout
+	if timeout <= 0 {
+		timeout = health.DefaultDialTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	sTime := time.Now()
+	conn := gc.Conn
+	if conn == nil {
+		creds := gc.Credentials
+		if creds == nil {
+			creds = insecure.NewCredentials()
+		}
+		if conn, err = grpc.DialContext(ctx, gc.Address, grpc.WithTransportCredentials(creds)); err != nil {
+			err = errors.New("Error connecting to `" + gc.Address + "`: " + err.Error())
+		} else {
+			defer conn.Close()
+		}
+	}
+
+	if err == nil {
+		var resp *healthpb.HealthCheckResponse
+		resp, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: gc.Service})
+		if err != nil {
+			err = errors.New("Error checking gRPC health: " + err.Error())
+		} else if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			state.Status = health.CRIT
+			state.Details = "Serving status: " + resp.GetStatus().String()
+		}
+	}
+	t := time.Since(sTime).Seconds()
+
+	if err != nil {
+		state.Status = health.CRIT
+		state.Details = err.Error()
+	}
+	return &health.DependencyInfo{
+		Name:         gc.Name,
+		Type:         gc.Type,
+		State:        state,
+		ResponseTime: t,
+	}
+}
+
+func (gc GRPCCheck) GetName() string {
+	return gc.Name
+}
+
+func (gc GRPCCheck) GetType() string {
+	return gc.Type
+}
@@ -0,0 +1,76 @@
+package grpccheck_test
+
+import (
+	"context"
+	"net"
+
+	"github.com/coupa/foundation-go/health"
+	. "github.com/coupa/foundation-go/health/grpccheck"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var _ = Describe("GRPC", func() {
+	Describe("Check", func() {
+		var (
+			lis  *bufconn.Listener
+			gs   *grpc.Server
+			hs   *grpchealth.Server
+			conn *grpc.ClientConn
+		)
+
+		BeforeEach(func() {
+			lis = bufconn.Listen(1024 * 1024)
+			gs = grpc.NewServer()
+			hs = grpchealth.NewServer()
+			healthpb.RegisterHealthServer(gs, hs)
+			go gs.Serve(lis)
+
+			var err error
+			conn, err = grpc.Dial("bufnet",
+				grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.Dial() }),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			conn.Close()
+			gs.Stop()
+		})
+
+		It("maps SERVING to OK and NOT_SERVING to CRIT", func() {
+			hs.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+			d := GRPCCheck{Name: "test", Type: health.TypeService, Conn: conn, Service: "orders"}.Check()
+			Expect(d.Name).To(Equal("test"))
+			Expect(d.Type).To(Equal(health.TypeService))
+			Expect(d.State.Status).To(Equal(health.OK))
+
+			hs.SetServingStatus("orders", healthpb.HealthCheckResponse_NOT_SERVING)
+			d = GRPCCheck{Name: "test", Conn: conn, Service: "orders"}.Check()
+			Expect(d.State.Status).To(Equal(health.CRIT))
+			Expect(d.State.Details).To(Equal("Serving status: NOT_SERVING"))
+		})
+
+		It("sets CRIT for an unknown service", func() {
+			d := GRPCCheck{Name: "test", Conn: conn, Service: "unknown"}.Check()
+			Expect(d.State.Status).To(Equal(health.CRIT))
+			Expect(d.State.Details).To(HavePrefix("Error checking gRPC health: "))
+			Expect(d.State.Details).To(ContainSubstring("NotFound"))
+		})
+
+		It("connects to the address when there is no connection", func() {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			go gs.Serve(ln)
+
+			d := GRPCCheck{Name: "test", Address: ln.Addr().String()}.Check()
+			Expect(d.State.Status).To(Equal(health.OK))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package grpccheck_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGRPCCheck(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GRPC Check Suite")
+}
@@ -1,6 +1,12 @@
 package health
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/coupa/foundation-go/config"
@@ -34,11 +40,35 @@ type AppInfo struct {
 
 	AppName  string `env:"APPLICATION_NAME"`
 	Hostname string `env:"HOSTNAME"`
+
+	//Build is shown in the detailed health. It is read by FillFromBuildInfo.
+	Build *BuildInfo
 }
 
-//FillFromENV will load pre-dfined values for env tags from environment variables
+//FillFromENV will load pre-dfined values for env tags from environment variables.
+//The version and revision are set from the parameters, or from the build info if
+//they are empty.
 func (ai *AppInfo) FillFromENV(version, revision string) *AppInfo {
 	config.PopulateEnvConfig(ai)
+	if version != "" {
+		ai.Version = version
+	}
+	if revision != "" {
+		ai.Revision = revision
+	}
+	return ai.FillFromBuildInfo()
+}
+
+//FillFromBuildInfo reads the build info and uses its version and revision when
+//Version or Revision is empty
+func (ai *AppInfo) FillFromBuildInfo() *AppInfo {
+	ai.Build = ReadBuildInfo()
+	if ai.Version == "" {
+		ai.Version = ai.Build.Version
+	}
+	if ai.Revision == "" {
+		ai.Revision = ai.Build.Revision
+	}
 	return ai
 }
 
@@ -75,6 +105,24 @@ type DependencyInfo struct {
 	Revision     string          `json:"revision"`
 	State        DependencyState `json:"state"`
 	ResponseTime float64         `json:"responseTime"`
+	//Criticality is one of the Criticality* constants. It is empty for required
+	//dependencies that do not declare it.
+	Criticality string `json:"criticality,omitempty"`
+	//Dependencies summarizes the dependency's own dependencies when it reports them
+	Dependencies *DependencySummary `json:"dependencies,omitempty"`
+	//RawState is the state of the last result before flap damping, and LastStatusChange
+	//is when the damped status last changed. They are set only by DampedCheck.
+	RawState         *DependencyState `json:"rawState,omitempty"`
+	LastStatusChange *time.Time       `json:"lastStatusChange,omitempty"`
+}
+
+//DependencySummary counts the statuses of a dependency's own dependencies
+type DependencySummary struct {
+	Total   int `json:"total"`
+	OK      int `json:"ok"`
+	WARN    int `json:"warn"`
+	CRIT    int `json:"crit"`
+	Unknown int `json:"unknown,omitempty"`
 }
 
 type DependencyState struct {
@@ -91,56 +139,169 @@ func UpTime() int64 {
 	return int64(time.Since(startTime).Seconds())
 }
 
+//SimpleHealth is the health document of the simple health check
+type SimpleHealth struct {
+	Status   string `json:"status"`
+	Version  string `json:"version"`
+	Revision string `json:"revision"`
+	//Details explains a status other than OK, such as when the server is draining
+	Details string `json:"details,omitempty"`
+}
+
 /**
   // To make data for detailed health check:
-  h := NewDetailedHealth(c, "some...")
+  h := NewDetailedHealth(ai, pi, "some...")
   d := DependencyInfo{...}
   h.AddDependency(d) // Add as many dependencies as needed.
+
+  Custom holds the additional data, which is rendered at the top level of the JSON
+  object. The custom keys that are the same as the standard fields are ignored.
 */
-type Health map[string]interface{}
+type DetailedHealth struct {
+	SimpleHealth
+	Name         string           `json:"name"`
+	Host         string           `json:"host"`
+	Description  string           `json:"description"`
+	Uptime       float64          `json:"uptime"`
+	Project      *ProjectInfo     `json:"project,omitempty"`
+	StatusReason string           `json:"statusReason,omitempty"`
+	Build        *BuildInfo       `json:"build,omitempty"`
+	Dependencies []DependencyInfo `json:"dependencies,omitempty"`
+
+	Custom map[string]interface{} `json:"-"`
+}
 
-func (h Health) AddDependency(d *DependencyInfo) {
+//detailedHealthFields are the JSON keys of the standard fields of DetailedHealth
+var detailedHealthFields = jsonFields(reflect.TypeOf(DetailedHealth{}))
+
+//jsonFields returns the JSON keys of the fields of the struct type, including
+//those of its embedded structs
+func jsonFields(t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch {
+		case name == "-" || f.PkgPath != "":
+		case f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct:
+			keys = append(keys, jsonFields(f.Type)...)
+		case name == "":
+			keys = append(keys, f.Name)
+		default:
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+func (h *DetailedHealth) AddDependency(d *DependencyInfo) {
 	if d == nil {
 		return
 	}
-	if h["dependencies"] == nil {
-		h["dependencies"] = []interface{}{*d}
-		return
+	h.Dependencies = append(h.Dependencies, *d)
+}
+
+func (h *DetailedHealth) SetDependencies(d []DependencyInfo) {
+	h.Dependencies = d
+}
+
+//MarshalJSON renders the standard fields and the custom data in one JSON object
+func (h DetailedHealth) MarshalJSON() ([]byte, error) {
+	type plain DetailedHealth
+	data, err := json.Marshal(plain(h))
+	if err != nil || len(h.Custom) == 0 {
+		return data, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range h.Custom {
+		if _, exists := fields[k]; exists {
+			continue
+		}
+		if fields[k], err = json.Marshal(v); err != nil {
+			return nil, errors.New("Error encoding custom health data `" + k + "`: " + err.Error())
+		}
 	}
-	h["dependencies"] = append(h["dependencies"].([]interface{}), *d)
+	return json.Marshal(fields)
 }
 
-func (h Health) SetDependencies(d []DependencyInfo) {
-	h["dependencies"] = d
+//UnmarshalJSON reads the standard fields and puts the other keys in Custom
+func (h *DetailedHealth) UnmarshalJSON(data []byte) error {
+	type plain DetailedHealth
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for _, k := range detailedHealthFields {
+		delete(fields, k)
+	}
+	p.Custom = nil
+	if len(fields) > 0 {
+		p.Custom = fields
+	}
+	*h = DetailedHealth(p)
+	return nil
+}
+
+//ParseHealth decodes a simple or detailed health document. The detailed health
+//fields are empty for a simple health.
+func ParseHealth(data []byte) (*DetailedHealth, error) {
+	var h DetailedHealth
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+//DecodeHealth reads and decodes a simple or detailed health document
+func DecodeHealth(r io.Reader) (*DetailedHealth, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseHealth(data)
 }
 
 //NewSimpleHealth creates a health struct that can be rendered for the simple health check.
-func NewSimpleHealth(ai *AppInfo, status string) Health {
+func NewSimpleHealth(ai *AppInfo, status string) *SimpleHealth {
 	if ai == nil {
 		ai = &AppInfo{}
 	}
-	return Health{
-		"status":   status,
-		"version":  ai.Version,
-		"revision": ai.Revision,
+	return &SimpleHealth{
+		Status:   status,
+		Version:  ai.Version,
+		Revision: ai.Revision,
 	}
 }
 
 //NewDetailedHealth creates a health struct without any dependency.
-func NewDetailedHealth(ai *AppInfo, pi *ProjectInfo, description string) Health {
+func NewDetailedHealth(ai *AppInfo, pi *ProjectInfo, description string) *DetailedHealth {
 	if ai == nil {
 		ai = &AppInfo{}
 	}
 	if pi == nil {
 		pi = &ProjectInfo{}
 	}
-	health := NewSimpleHealth(ai, OK)
-	health["project"] = *pi
-	health["host"] = ai.Hostname
-	health["description"] = description
-	health["name"] = ai.AppName
-	health["uptime"] = UpTime()
-	return health
+	project := *pi
+	build := ai.Build
+	if build == nil {
+		build = currentBuildInfo()
+	}
+	return &DetailedHealth{
+		SimpleHealth: *NewSimpleHealth(ai, OK),
+		Project:      &project,
+		Host:         ai.Hostname,
+		Description:  description,
+		Name:         ai.AppName,
+		Uptime:       float64(UpTime()),
+		Build:        build,
+	}
 }
 
 type HealthChecker interface {
@@ -151,7 +312,8 @@ type HealthChecker interface {
 
 type AdditionalHealthData struct {
 	DependencyChecks []HealthChecker
-	//The custom data can override the default health detail values
+	//The custom data is added to the detailed health. It cannot override the
+	//standard fields, such as the status.
 	DataProvider func(*gin.Context) map[string]interface{}
 
 	//Description is set in server.RegisterDetailedHealth function, so there is no need
@@ -159,6 +321,16 @@ type AdditionalHealthData struct {
 	Description string
 }
 
+//NormalizeStatus converts a status to one of OK, WARN, or CRIT regardless of its
+//casing and surrounding spaces. It returns an empty string for an unknown status.
+func NormalizeStatus(status string) string {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	if CriticalLevels[status] == 0 {
+		return ""
+	}
+	return status
+}
+
 //IsMoreCritical checks if a's status level is more critical than b
 func IsMoreCritical(a string, b string) bool {
 	return CriticalLevels[a] > CriticalLevels[b]
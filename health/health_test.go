@@ -1,25 +1,98 @@
 package health
 
 import (
+	"bytes"
+	"encoding/json"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
 var _ = Describe("Health", func() {
 	Describe("AddDependency", func() {
-		It("can add to an empty Health struct", func() {
-			h := Health{}
+		It("can add to an empty DetailedHealth struct", func() {
+			h := DetailedHealth{}
 			h.AddDependency(&DependencyInfo{Name: "test"})
-			ds := h["dependencies"].([]interface{})
-			Expect(ds).To(HaveLen(1))
-			d := ds[0].(DependencyInfo)
-			Expect(d.Name).To(Equal("test"))
+			Expect(h.Dependencies).To(HaveLen(1))
+			Expect(h.Dependencies[0].Name).To(Equal("test"))
+
+			h.AddDependency(&DependencyInfo{Name: "test2"})
+			h.AddDependency(nil)
+			Expect(h.Dependencies).To(HaveLen(2))
+			Expect(h.Dependencies[1].Name).To(Equal("test2"))
+		})
 
+		It("can add after SetDependencies", func() {
+			h := NewDetailedHealth(nil, nil, "")
+			h.SetDependencies([]DependencyInfo{{Name: "test"}})
 			h.AddDependency(&DependencyInfo{Name: "test2"})
-			ds = h["dependencies"].([]interface{})
-			Expect(ds).To(HaveLen(2))
-			d = ds[1].(DependencyInfo)
-			Expect(d.Name).To(Equal("test2"))
+			Expect(h.Dependencies).To(HaveLen(2))
+		})
+	})
+
+	Describe("DetailedHealth JSON", func() {
+		It("renders the custom data without overriding the standard fields", func() {
+			h := NewDetailedHealth(&AppInfo{Version: "v1", AppName: "app"}, &ProjectInfo{Repo: "repo"}, "desc")
+			h.Custom = map[string]interface{}{"status": 1, "extra": []string{"a"}}
+			d, err := json.Marshal(h)
+			Expect(err).NotTo(HaveOccurred())
+
+			var m map[string]interface{}
+			Expect(json.Unmarshal(d, &m)).To(Succeed())
+			Expect(m["status"]).To(Equal(OK))
+			Expect(m["version"]).To(Equal("v1"))
+			Expect(m["name"]).To(Equal("app"))
+			Expect(m["description"]).To(Equal("desc"))
+			Expect(m["project"].(map[string]interface{})["repo"]).To(Equal("repo"))
+			Expect(m["extra"]).To(Equal([]interface{}{"a"}))
+			Expect(m).NotTo(HaveKey("dependencies"))
+			Expect(m).To(HaveKey("uptime"))
+		})
+
+		It("decodes the document back with the custom data", func() {
+			h := NewDetailedHealth(&AppInfo{Version: "v1"}, nil, "desc")
+			h.Status = WARN
+			h.StatusReason = "WARN: required dependency `a` is WARN"
+			h.AddDependency(&DependencyInfo{Name: "a", State: DependencyState{Status: WARN}})
+			h.Custom = map[string]interface{}{"extra": "data"}
+			d, _ := json.Marshal(h)
+
+			decoded, err := DecodeHealth(bytes.NewReader(d))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decoded.Status).To(Equal(WARN))
+			Expect(decoded.Version).To(Equal("v1"))
+			Expect(decoded.Description).To(Equal("desc"))
+			Expect(decoded.StatusReason).To(Equal(h.StatusReason))
+			Expect(decoded.Dependencies).To(Equal(h.Dependencies))
+			Expect(decoded.Custom).To(Equal(map[string]interface{}{"extra": "data"}))
+		})
+
+		It("decodes a simple health", func() {
+			h, err := ParseHealth([]byte(`{"status":"OK","version":"v1","revision":"r1"}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(h.SimpleHealth).To(Equal(SimpleHealth{Status: OK, Version: "v1", Revision: "r1"}))
+			Expect(h.Custom).To(BeNil())
+
+			_, err = ParseHealth([]byte(`[]`))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("derives the standard fields from the JSON tags", func() {
+			Expect(detailedHealthFields).To(ConsistOf(
+				"status", "version", "revision", "details", "name", "host", "description", "uptime",
+				"project", "statusReason", "build", "dependencies",
+			))
+		})
+	})
+
+	Describe("NormalizeStatus", func() {
+		It("converts known statuses regardless of casing", func() {
+			Expect(NormalizeStatus("ok")).To(Equal(OK))
+			Expect(NormalizeStatus(" Warn ")).To(Equal(WARN))
+			Expect(NormalizeStatus("CRIT")).To(Equal(CRIT))
+
+			Expect(NormalizeStatus("")).To(BeEmpty())
+			Expect(NormalizeStatus("healthy")).To(BeEmpty())
 		})
 	})
 
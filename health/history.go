@@ -0,0 +1,155 @@
+package health
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	//DefaultHistorySize is the number of results kept per dependency by default
+	DefaultHistorySize = 100
+)
+
+//HistoryEntry is a past health check result of a dependency
+type HistoryEntry struct {
+	Status       string    `json:"status"`
+	Details      string    `json:"details,omitempty"`
+	ResponseTime float64   `json:"responseTime"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+//HistorySummary is the statistics of the history entries of a dependency.
+//Availability is the percentage of results that are not CRIT, and the
+//percentiles are of the response time in seconds.
+type HistorySummary struct {
+	Count        int     `json:"count"`
+	OK           int     `json:"ok"`
+	WARN         int     `json:"warn"`
+	CRIT         int     `json:"crit"`
+	Availability float64 `json:"availability"`
+	P50          float64 `json:"p50"`
+	P95          float64 `json:"p95"`
+	P99          float64 `json:"p99"`
+}
+
+//History keeps the most recent health check results of every dependency in
+//ring buffers. It is safe for concurrent use.
+type History struct {
+	size    int
+	mu      sync.RWMutex
+	entries map[string]*historyRing
+}
+
+//historyRing is a fixed-size ring buffer of the entries of one dependency
+type historyRing struct {
+	entries []HistoryEntry
+	next    int
+	full    bool
+}
+
+//NewHistory creates a history that keeps up to size results per dependency. The
+//size defaults to DefaultHistorySize if it is <= 0.
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	return &History{size: size, entries: map[string]*historyRing{}}
+}
+
+//Record adds the result of a dependency at the time t. The oldest result of the
+//dependency is dropped when its buffer is full.
+func (h *History) Record(di *DependencyInfo, t time.Time) {
+	if di == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r := h.entries[di.Name]
+	if r == nil {
+		r = &historyRing{entries: make([]HistoryEntry, h.size)}
+		h.entries[di.Name] = r
+	}
+	r.entries[r.next] = HistoryEntry{
+		Status:       di.State.Status,
+		Details:      di.State.Details,
+		ResponseTime: di.ResponseTime,
+		Timestamp:    t,
+	}
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+//Names returns the sorted names of the dependencies in the history
+func (h *History) Names() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	names := make([]string, 0, len(h.entries))
+	for name := range h.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Entries returns the entries of the dependency from the oldest to the newest
+//within the time range. A zero from or to leaves that end of the range open.
+func (h *History) Entries(name string, from, to time.Time) []HistoryEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	r := h.entries[name]
+	if r == nil {
+		return nil
+	}
+	start, count := 0, r.next
+	if r.full {
+		start, count = r.next, len(r.entries)
+	}
+	var entries []HistoryEntry
+	for i := 0; i < count; i++ {
+		e := r.entries[(start+i)%len(r.entries)]
+		if (!from.IsZero() && e.Timestamp.Before(from)) || (!to.IsZero() && e.Timestamp.After(to)) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+//SummarizeHistory computes the statistics of the entries
+func SummarizeHistory(entries []HistoryEntry) HistorySummary {
+	s := HistorySummary{Count: len(entries)}
+	if len(entries) == 0 {
+		return s
+	}
+	times := make([]float64, len(entries))
+	for i, e := range entries {
+		switch e.Status {
+		case OK:
+			s.OK++
+		case WARN:
+			s.WARN++
+		case CRIT:
+			s.CRIT++
+		}
+		times[i] = e.ResponseTime
+	}
+	sort.Float64s(times)
+	s.Availability = float64(len(entries)-s.CRIT) / float64(len(entries)) * 100
+	s.P50 = percentile(times, 50)
+	s.P95 = percentile(times, 95)
+	s.P99 = percentile(times, 99)
+	return s
+}
+
+//percentile returns the nearest-rank percentile p of the sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
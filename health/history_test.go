@@ -0,0 +1,66 @@
+package health
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	dep := func(name, status string, rt float64) *DependencyInfo {
+		return &DependencyInfo{Name: name, ResponseTime: rt, State: DependencyState{Status: status, Details: "d"}}
+	}
+
+	Describe("Record", func() {
+		It("keeps the most recent results per dependency", func() {
+			h := NewHistory(3)
+			for i := 0; i < 5; i++ {
+				h.Record(dep("a", OK, float64(i)), base.Add(time.Duration(i)*time.Minute))
+			}
+			h.Record(dep("b", CRIT, 1), base)
+			h.Record(nil, base)
+
+			Expect(h.Names()).To(Equal([]string{"a", "b"}))
+			entries := h.Entries("a", time.Time{}, time.Time{})
+			Expect(entries).To(HaveLen(3))
+			Expect(entries[0].ResponseTime).To(Equal(2.0))
+			Expect(entries[2].ResponseTime).To(Equal(4.0))
+			Expect(entries[2].Timestamp).To(Equal(base.Add(4 * time.Minute)))
+			Expect(h.Entries("b", time.Time{}, time.Time{})).To(Equal([]HistoryEntry{{Status: CRIT, Details: "d", ResponseTime: 1, Timestamp: base}}))
+			Expect(h.Entries("c", time.Time{}, time.Time{})).To(BeEmpty())
+		})
+	})
+
+	Describe("Entries", func() {
+		It("filters by the time range", func() {
+			h := NewHistory(0)
+			for i := 0; i < 5; i++ {
+				h.Record(dep("a", OK, float64(i)), base.Add(time.Duration(i)*time.Minute))
+			}
+			entries := h.Entries("a", base.Add(time.Minute), base.Add(3*time.Minute))
+			Expect(entries).To(HaveLen(3))
+			Expect(entries[0].ResponseTime).To(Equal(1.0))
+			Expect(h.Entries("a", base.Add(4*time.Minute), time.Time{})).To(HaveLen(1))
+		})
+	})
+
+	Describe("SummarizeHistory", func() {
+		It("computes the availability and the response time percentiles", func() {
+			var entries []HistoryEntry
+			for i := 1; i <= 100; i++ {
+				status := OK
+				if i%10 == 0 {
+					status = CRIT
+				} else if i%10 == 5 {
+					status = WARN
+				}
+				entries = append(entries, HistoryEntry{Status: status, ResponseTime: float64(101 - i)})
+			}
+			s := SummarizeHistory(entries)
+			Expect(s).To(Equal(HistorySummary{Count: 100, OK: 80, WARN: 10, CRIT: 10, Availability: 90, P50: 50, P95: 95, P99: 99}))
+			Expect(SummarizeHistory(nil)).To(Equal(HistorySummary{}))
+		})
+	})
+})
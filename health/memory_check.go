@@ -0,0 +1,75 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//MemoryCheck checks the memory of the current process. The resident set size
+//(RSS) is read from /proc/self/statm where it is available; otherwise the memory
+//obtained from the OS by the Go runtime is used instead.
+type MemoryCheck struct {
+	Name string
+	Type string
+	//MaxRSS sets WARN or CRIT when the memory in bytes reaches the levels
+	MaxRSS Thresholds
+	//MaxHeap sets WARN or CRIT when the allocated heap in bytes reaches the levels
+	MaxHeap Thresholds
+}
+
+func (mc MemoryCheck) Check() *DependencyInfo {
+	state := DependencyState{Status: OK}
+
+	sTime := time.Now()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	source := "rss"
+	rss, err := readRSS()
+	if err != nil {
+		source = "sys"
+		rss = ms.Sys
+	}
+	t := time.Since(sTime).Seconds()
+
+	raiseStatus(&state, mc.MaxRSS.statusAbove(float64(rss)))
+	raiseStatus(&state, mc.MaxHeap.statusAbove(float64(ms.HeapAlloc)))
+	state.Details = fmt.Sprintf("%s=%d heap_alloc=%d heap_sys=%d num_gc=%d", source, rss, ms.HeapAlloc, ms.HeapSys, ms.NumGC)
+
+	return &DependencyInfo{
+		Name:         mc.Name,
+		Type:         mc.Type,
+		State:        state,
+		ResponseTime: t,
+	}
+}
+
+func (mc MemoryCheck) GetName() string {
+	return mc.Name
+}
+
+func (mc MemoryCheck) GetType() string {
+	return mc.Type
+}
+
+//readRSS reads the resident set size in bytes of the current process
+func readRSS() (uint64, error) {
+	data, err := ioutil.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, errors.New("unexpected format of /proc/self/statm")
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return pages * uint64(os.Getpagesize()), nil
+}
@@ -0,0 +1,23 @@
+package health
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Memory", func() {
+	Describe("Check", func() {
+		It("reports the memory and applies the thresholds", func() {
+			d := MemoryCheck{Name: "test"}.Check()
+			Expect(d.Name).To(Equal("test"))
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.State.Details).To(MatchRegexp(`^(rss|sys)=\d+ heap_alloc=\d+`))
+
+			d = MemoryCheck{Name: "test", MaxRSS: Thresholds{Warn: 1}}.Check()
+			Expect(d.State.Status).To(Equal(WARN))
+
+			d = MemoryCheck{Name: "test", MaxRSS: Thresholds{Warn: 1}, MaxHeap: Thresholds{Crit: 1}}.Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+		})
+	})
+})
@@ -0,0 +1,122 @@
+package health
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	//PrometheusContentType is the content type of the Prometheus text format
+	PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var (
+	//PrometheusStatusValues converts the statuses to the values of the status gauges
+	PrometheusStatusValues = map[string]int{
+		OK:   0,
+		WARN: 1,
+		CRIT: 2,
+	}
+
+	prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+//CheckedDependency is the result of a dependency check and when it was checked
+type CheckedDependency struct {
+	*DependencyInfo
+	CheckedAt time.Time
+}
+
+//WritePrometheus writes the overall status, the uptime, the build info and the
+//results of the dependency checks in the Prometheus text format. An unknown
+//status is exported as CRIT. While the server is draining, the overall status is
+//CRIT and health_draining is 1.
+func WritePrometheus(w io.Writer, ai *AppInfo, deps []CheckedDependency, draining bool) error {
+	bw := bufio.NewWriter(w)
+	if ai == nil {
+		ai = &AppInfo{}
+	}
+	sorted := make([]CheckedDependency, 0, len(deps))
+	infos := make([]*DependencyInfo, 0, len(deps))
+	for _, cd := range deps {
+		if cd.DependencyInfo != nil {
+			sorted = append(sorted, cd)
+			infos = append(infos, cd.DependencyInfo)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	status, _ := AggregateStatus(infos)
+	drainingValue := 0
+	if draining {
+		status, drainingValue = CRIT, 1
+	}
+	writePrometheusFamily(bw, "health_status", "gauge", "Overall health status: 0 is OK, 1 is WARN and 2 is CRIT.")
+	fmt.Fprintf(bw, "health_status %d\n", prometheusStatus(status))
+
+	writePrometheusFamily(bw, "health_draining", "gauge", "Whether the server is draining: 1 is draining.")
+	fmt.Fprintf(bw, "health_draining %d\n", drainingValue)
+
+	writePrometheusFamily(bw, "health_uptime_seconds", "gauge", "Application uptime in seconds.")
+	fmt.Fprintf(bw, "health_uptime_seconds %d\n", UpTime())
+
+	writePrometheusFamily(bw, "health_build_info", "gauge", "Build information of the application.")
+	fmt.Fprintf(bw, "health_build_info{%s} 1\n", prometheusLabels("app", ai.AppName, "version", ai.Version, "revision", ai.Revision))
+
+	if len(sorted) > 0 {
+		writePrometheusFamily(bw, "health_dependency_status", "gauge", "Dependency status: 0 is OK, 1 is WARN and 2 is CRIT.")
+		for _, cd := range sorted {
+			fmt.Fprintf(bw, "health_dependency_status{%s} %d\n", dependencyLabels(cd.DependencyInfo, true), prometheusStatus(cd.State.Status))
+		}
+		writePrometheusFamily(bw, "health_dependency_response_time_seconds", "gauge", "Response time of the last dependency check in seconds.")
+		for _, cd := range sorted {
+			fmt.Fprintf(bw, "health_dependency_response_time_seconds{%s} %s\n", dependencyLabels(cd.DependencyInfo, false), formatPrometheusFloat(cd.ResponseTime))
+		}
+		writePrometheusFamily(bw, "health_dependency_last_check_timestamp_seconds", "gauge", "Unix time of the last dependency check.")
+		for _, cd := range sorted {
+			ts := formatPrometheusFloat(float64(cd.CheckedAt.UnixNano()) / 1e9)
+			fmt.Fprintf(bw, "health_dependency_last_check_timestamp_seconds{%s} %s\n", dependencyLabels(cd.DependencyInfo, false), ts)
+		}
+	}
+	return bw.Flush()
+}
+
+func writePrometheusFamily(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func prometheusStatus(status string) int {
+	if v, ok := PrometheusStatusValues[NormalizeStatus(status)]; ok {
+		return v
+	}
+	return PrometheusStatusValues[CRIT]
+}
+
+func dependencyLabels(di *DependencyInfo, withCriticality bool) string {
+	if !withCriticality {
+		return prometheusLabels("name", di.Name, "type", di.Type)
+	}
+	criticality := di.Criticality
+	if criticality == "" {
+		criticality = CriticalityRequired
+	}
+	return prometheusLabels("name", di.Name, "type", di.Type, "criticality", criticality)
+}
+
+//prometheusLabels formats the label name and value pairs like `a="1",b="2"`
+func prometheusLabels(pairs ...string) string {
+	labels := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels = append(labels, pairs[i]+`="`+prometheusLabelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return strings.Join(labels, ",")
+}
+
+func formatPrometheusFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
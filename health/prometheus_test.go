@@ -0,0 +1,59 @@
+package health
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prometheus", func() {
+	Describe("WritePrometheus", func() {
+		It("writes the health in the Prometheus text format", func() {
+			deps := []CheckedDependency{
+				{
+					DependencyInfo: &DependencyInfo{Name: "redis", Type: TypeInternal, ResponseTime: 0.25, Criticality: CriticalityOptional, State: DependencyState{Status: CRIT}},
+					CheckedAt:      time.Unix(1500000000, 500000000),
+				},
+				{
+					DependencyInfo: &DependencyInfo{Name: "my\"sql", Type: TypeInternal, ResponseTime: 0.5, State: DependencyState{Status: OK}},
+					CheckedAt:      time.Unix(1400000000, 0),
+				},
+				{},
+			}
+			ai := &AppInfo{AppName: "app", Version: "1.0.0", Revision: "abc"}
+			var buf bytes.Buffer
+			Expect(WritePrometheus(&buf, ai, deps, false)).To(Succeed())
+
+			out := buf.String()
+			Expect(out).To(ContainSubstring("# TYPE health_status gauge\nhealth_status 1\n"))
+			Expect(out).To(MatchRegexp(`(?m)^health_uptime_seconds \d+$`))
+			Expect(out).To(ContainSubstring(`health_build_info{app="app",version="1.0.0",revision="abc"} 1`))
+			Expect(out).To(ContainSubstring(`health_dependency_status{name="my\"sql",type="internal",criticality="required"} 0
+health_dependency_status{name="redis",type="internal",criticality="optional"} 2
+`))
+			Expect(out).To(ContainSubstring(`health_dependency_response_time_seconds{name="redis",type="internal"} 0.25`))
+			Expect(out).To(ContainSubstring(`health_dependency_last_check_timestamp_seconds{name="redis",type="internal"} 1.5000000005e+09`))
+			Expect(out).To(ContainSubstring(`health_dependency_last_check_timestamp_seconds{name="my\"sql",type="internal"} 1.4e+09`))
+		})
+
+		It("writes only the application metrics without dependencies", func() {
+			var buf bytes.Buffer
+			Expect(WritePrometheus(&buf, nil, nil, false)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring("health_status 0\n"))
+			Expect(buf.String()).To(ContainSubstring("health_draining 0\n"))
+			Expect(buf.String()).To(ContainSubstring(`health_build_info{app="",version="",revision=""} 1`))
+			Expect(buf.String()).NotTo(ContainSubstring("health_dependency"))
+		})
+
+		It("writes the overall status as CRIT while draining", func() {
+			deps := []CheckedDependency{{DependencyInfo: &DependencyInfo{Name: "mysql", State: DependencyState{Status: OK}}}}
+			var buf bytes.Buffer
+			Expect(WritePrometheus(&buf, nil, deps, true)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring("health_status 2\n"))
+			Expect(buf.String()).To(ContainSubstring("health_draining 1\n"))
+			Expect(buf.String()).To(ContainSubstring(`health_dependency_status{name="mysql",type="",criticality="required"} 0`))
+		})
+	})
+})
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+//DefaultMemberTimeout is how long a QuorumCheck waits for its checks when its
+//MemberTimeout is not set. It is less than the timeout of the server so that a
+//hung member does not time out the whole group.
+var DefaultMemberTimeout = 3 * time.Second
+
+//QuorumCheck checks a group of interchangeable dependencies, like the replicas
+//of a service. The group is CRIT only if fewer than MinOK checks are OK. It is
+//WARN if enough but not all checks are OK, and OK if all checks are OK. A check
+//that panics or does not finish within MemberTimeout counts as CRIT. A quorum
+//without checks, or with MinOK greater than the number of checks, is CRIT.
+type QuorumCheck struct {
+	Name   string
+	Type   string
+	Checks []HealthChecker
+	//MinOK is the minimum number of OK checks. If it is <= 0, all checks must be OK.
+	MinOK int
+	//MemberTimeout is how long to wait for the checks. It defaults to
+	//DefaultMemberTimeout.
+	MemberTimeout time.Duration
+}
+
+//NewQuorumCheck creates a check that is CRIT only if fewer than minOK of the
+//checks are OK, such as 2 of 3 replicas.
+func NewQuorumCheck(name, checkType string, minOK int, checks ...HealthChecker) *QuorumCheck {
+	return &QuorumCheck{
+		Name:   name,
+		Type:   checkType,
+		Checks: checks,
+		MinOK:  minOK,
+	}
+}
+
+func (qc *QuorumCheck) Check() *DependencyInfo {
+	if len(qc.Checks) == 0 {
+		return &DependencyInfo{
+			Name:  qc.Name,
+			Type:  qc.Type,
+			State: DependencyState{Status: CRIT, Details: "The quorum has no checks"},
+		}
+	}
+	timeout := qc.MemberTimeout
+	if timeout <= 0 {
+		timeout = DefaultMemberTimeout
+	}
+	sTime := time.Now()
+	results := CheckExecutor{Timeout: timeout}.Run(qc.Checks)
+	t := time.Since(sTime).Seconds()
+
+	minOK := qc.MinOK
+	if minOK <= 0 {
+		minOK = len(results)
+	}
+	numOK := 0
+	var members []string
+	for _, di := range results {
+		if di.State.Status == OK {
+			numOK++
+		}
+		member := di.Name + ": " + di.State.Status
+		if di.State.Details != "" && di.State.Status != OK {
+			member += " (" + di.State.Details + ")"
+		}
+		members = append(members, member)
+	}
+
+	state := DependencyState{Status: OK}
+	if numOK < minOK {
+		state.Status = CRIT
+	} else if numOK < len(results) {
+		state.Status = WARN
+	}
+	state.Details = fmt.Sprintf("%d of %d checks are OK, %d required", numOK, len(results), minOK)
+	if len(members) > 0 {
+		state.Details += "; " + strings.Join(members, "; ")
+	}
+
+	return &DependencyInfo{
+		Name:         qc.Name,
+		Type:         qc.Type,
+		State:        state,
+		ResponseTime: t,
+	}
+}
+
+func (qc *QuorumCheck) GetName() string {
+	return qc.Name
+}
+
+func (qc *QuorumCheck) GetType() string {
+	return qc.Type
+}
@@ -0,0 +1,64 @@
+package health
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Quorum", func() {
+	Describe("Check", func() {
+		It("is CRIT only if fewer than MinOK checks are OK", func() {
+			a := stubCheck{name: "a", status: OK}
+			b := stubCheck{name: "b", status: OK}
+			down := stubCheck{name: "c", status: CRIT}
+
+			d := NewQuorumCheck("replicas", TypeService, 2, a, b, stubCheck{name: "c", status: OK}).Check()
+			Expect(d.Name).To(Equal("replicas"))
+			Expect(d.Type).To(Equal(TypeService))
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.State.Details).To(HavePrefix("3 of 3 checks are OK, 2 required"))
+
+			d = NewQuorumCheck("replicas", TypeService, 2, a, b, down).Check()
+			Expect(d.State.Status).To(Equal(WARN))
+			Expect(d.State.Details).To(Equal("2 of 3 checks are OK, 2 required; a: OK; b: OK; c: CRIT (stub CRIT)"))
+
+			d = NewQuorumCheck("replicas", TypeService, 2, a, down, down).Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+			Expect(d.State.Details).To(HavePrefix("1 of 3 checks are OK, 2 required"))
+		})
+
+		It("counts a check that panics or times out as CRIT", func() {
+			panicky := stubCheck{name: "b", check: func() *DependencyInfo { panic("boom") }}
+			hung := stubCheck{name: "c", check: func() *DependencyInfo {
+				time.Sleep(time.Second)
+				return &DependencyInfo{Name: "c", State: DependencyState{Status: OK}}
+			}}
+			qc := NewQuorumCheck("replicas", TypeService, 1, stubCheck{name: "a", status: OK}, panicky, hung)
+			qc.MemberTimeout = 50 * time.Millisecond
+
+			sTime := time.Now()
+			d := qc.Check()
+			Expect(time.Since(sTime)).To(BeNumerically("<", 500*time.Millisecond))
+			Expect(d.State.Status).To(Equal(WARN))
+			Expect(d.State.Details).To(HavePrefix("1 of 3 checks are OK, 1 required; a: OK; b: CRIT (Health check panicked: boom); c: CRIT (Health check timed out after 0.05"))
+		})
+
+		It("requires all checks to be OK without MinOK", func() {
+			d := NewQuorumCheck("replicas", TypeService, 0, stubCheck{name: "a", status: OK}, stubCheck{name: "b", status: WARN}).Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+			Expect(d.State.Details).To(HavePrefix("1 of 2 checks are OK, 2 required"))
+		})
+
+		It("is CRIT without checks or with more MinOK than checks", func() {
+			d := NewQuorumCheck("replicas", TypeService, 1).Check()
+			Expect(d.Name).To(Equal("replicas"))
+			Expect(d.State).To(Equal(DependencyState{Status: CRIT, Details: "The quorum has no checks"}))
+
+			d = NewQuorumCheck("replicas", TypeService, 3, stubCheck{name: "a", status: OK}, stubCheck{name: "b", status: OK}).Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+			Expect(d.State.Details).To(HavePrefix("2 of 2 checks are OK, 3 required"))
+		})
+	})
+})
@@ -2,38 +2,101 @@ package health
 
 import (
 	"errors"
-	"github.com/go-redis/redis"
+	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/go-redis/redis"
+)
+
+var (
+	redisVersionRegexp = regexp.MustCompile(`redis_version:\s*(\w|\-|\.)+`)
+	redisSHA1Regexp    = regexp.MustCompile(`redis_git_sha1:\s*(\w|\-|\.)+`)
 )
 
 type RedisCheck struct {
-	Name   string
-	Type   string
+	Name string
+	Type string
+	//Client can be a *redis.Client (including the ones created by redis.NewFailoverClient),
+	//a *redis.ClusterClient, a *redis.Ring, or any other redis.Cmdable such as
+	//a redis.UniversalClient.
+	//For a cluster, any master node down is CRIT and any replica node down is WARN.
+	//For a ring, any shard down is WARN and all shards down is CRIT.
 	Client interface{}
+
+	//MaxMemoryUsage sets WARN when the ratio of used_memory to maxmemory of any
+	//node reaches it, such as 0.9. It is ignored when it is <= 0 or when maxmemory
+	//is not set on the node.
+	MaxMemoryUsage float64
+	//MaxConnectedClients sets WARN when connected_clients of any node exceeds it.
+	//It is ignored when it is <= 0.
+	MaxConnectedClients int64
+}
+
+//redisNode is the result of querying the info of a single Redis node
+type redisNode struct {
+	addr    string
+	replica bool
+	info    map[string]string
+	err     error
 }
 
 func (rc RedisCheck) Check() *DependencyInfo {
 	var err error
 	var t float64
+	var nodes []*redisNode
 	ver := ""
 	sha1 := ""
 	state := DependencyState{Status: OK}
 
 	sTime := time.Now()
 	switch c := rc.Client.(type) {
-	case *redis.Client:
-		if s, er1 := c.Info("Server").Result(); er1 == nil {
-			ver = getMatch(s, regexp.MustCompile(`redis_version:\s*(\w|\-|\.)+`))
-			sha1 = getMatch(s, regexp.MustCompile(`redis_git_sha1:\s*(\w|\-|\.)+`))
+	case *redis.ClusterClient:
+		nodes, err = rc.clusterNodes(c)
+	case *redis.Ring:
+		nodes, err = rc.ringNodes(c)
+	case redis.Cmdable:
+		n := queryRedisNode(c, "")
+		if n.err != nil {
+			err = n.err
 		} else {
-			err = errors.New("Error querying Redis info: " + er1.Error())
+			nodes = []*redisNode{n}
 		}
-		t = time.Since(sTime).Seconds()
 	default:
 		err = errors.New("Unknown type of Redis client")
 	}
+	t = time.Since(sTime).Seconds()
+
+	if err == nil {
+		var details []string
+		for _, n := range nodes {
+			if n.err != nil {
+				status := CRIT
+				if n.replica {
+					status = WARN
+				}
+				if IsMoreCritical(status, state.Status) {
+					state.Status = status
+				}
+				details = append(details, n.describe())
+				continue
+			}
+			if ver == "" {
+				ver = getMatch("redis_version:"+n.info["redis_version"], redisVersionRegexp)
+				sha1 = getMatch("redis_git_sha1:"+n.info["redis_git_sha1"], redisSHA1Regexp)
+			}
+			details = append(details, n.describe())
+			details = append(details, rc.verifyNode(n, &state)...)
+		}
+		if len(nodes) > 0 && allRedisNodesDown(nodes) {
+			state.Status = CRIT
+		}
+		state.Details = strings.Join(details, "; ")
+	}
 
 	if err != nil {
 		state.Status = CRIT
@@ -57,6 +120,157 @@ func (rc RedisCheck) GetType() string {
 	return rc.Type
 }
 
+//clusterNodes queries the info of every master and replica node of the cluster
+func (rc RedisCheck) clusterNodes(c *redis.ClusterClient) ([]*redisNode, error) {
+	var mu sync.Mutex
+	var nodes []*redisNode
+	collect := func(replica bool) func(*redis.Client) error {
+		return func(client *redis.Client) error {
+			n := queryRedisNode(client, client.Options().Addr)
+			n.replica = replica
+			mu.Lock()
+			nodes = append(nodes, n)
+			mu.Unlock()
+			return nil
+		}
+	}
+	if err := c.ForEachMaster(collect(false)); err != nil {
+		return nil, errors.New("Error loading Redis cluster state: " + err.Error())
+	}
+	if err := c.ForEachSlave(collect(true)); err != nil {
+		return nil, errors.New("Error loading Redis cluster state: " + err.Error())
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("No Redis cluster node is found")
+	}
+	sortRedisNodes(nodes)
+	return nodes, nil
+}
+
+//ringNodes queries the info of every shard of the ring. A shard that the ring
+//already considers down is treated as a replica so that it is WARN.
+func (rc RedisCheck) ringNodes(c *redis.Ring) ([]*redisNode, error) {
+	var mu sync.Mutex
+	var nodes []*redisNode
+	live := map[string]bool{}
+	c.ForEachShard(func(client *redis.Client) error {
+		addr := client.Options().Addr
+		n := queryRedisNode(client, addr)
+		n.replica = true
+		mu.Lock()
+		nodes = append(nodes, n)
+		live[addr] = true
+		mu.Unlock()
+		return nil
+	})
+	for _, addr := range c.Options().Addrs {
+		if !live[addr] {
+			nodes = append(nodes, &redisNode{addr: addr, replica: true, err: errors.New("shard is down")})
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("No Redis ring shard is found")
+	}
+	sortRedisNodes(nodes)
+	return nodes, nil
+}
+
+//verifyNode checks the node info against the thresholds. It sets WARN on the
+//state and returns the reasons if any threshold is crossed.
+func (rc RedisCheck) verifyNode(n *redisNode, state *DependencyState) []string {
+	var reasons []string
+	prefix := ""
+	if n.addr != "" {
+		prefix = n.addr + ": "
+	}
+	used, _ := strconv.ParseInt(n.info["used_memory"], 10, 64)
+	max, _ := strconv.ParseInt(n.info["maxmemory"], 10, 64)
+	if rc.MaxMemoryUsage > 0 && max > 0 {
+		usage := float64(used) / float64(max)
+		if usage >= rc.MaxMemoryUsage {
+			reasons = append(reasons, fmt.Sprintf("%sMemory usage %.2f reached the threshold %.2f", prefix, usage, rc.MaxMemoryUsage))
+		}
+	}
+	clients, _ := strconv.ParseInt(n.info["connected_clients"], 10, 64)
+	if rc.MaxConnectedClients > 0 && clients > rc.MaxConnectedClients {
+		reasons = append(reasons, fmt.Sprintf("%sConnected clients %d exceeded the threshold %d", prefix, clients, rc.MaxConnectedClients))
+	}
+	if len(reasons) > 0 && IsMoreCritical(WARN, state.Status) {
+		state.Status = WARN
+	}
+	return reasons
+}
+
+//describe summarizes the node as the details of the dependency state
+func (n *redisNode) describe() string {
+	prefix := ""
+	if n.addr != "" {
+		prefix = n.addr + ": "
+	}
+	if n.err != nil {
+		role := "master"
+		if n.replica {
+			role = "replica"
+		}
+		return fmt.Sprintf("%s%s is down: %s", prefix, role, n.err.Error())
+	}
+	return fmt.Sprintf("%srole=%s used_memory=%s maxmemory=%s connected_clients=%s",
+		prefix, n.info["role"], valueOrZero(n.info["used_memory"]), valueOrZero(n.info["maxmemory"]), valueOrZero(n.info["connected_clients"]))
+}
+
+func queryRedisNode(c redis.Cmdable, addr string) *redisNode {
+	n := &redisNode{addr: addr}
+	s, err := c.Info().Result()
+	if err != nil {
+		n.err = errors.New("Error querying Redis info: " + err.Error())
+		return n
+	}
+	n.info = parseRedisInfo(s)
+	return n
+}
+
+//parseRedisInfo converts the "key:value" lines of the INFO command into a map
+func parseRedisInfo(s string) map[string]string {
+	info := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(line, ":"); i > 0 {
+			info[line[:i]] = getValueFromPair(line, ":")
+		}
+	}
+	return info
+}
+
+func allRedisNodesDown(nodes []*redisNode) bool {
+	for _, n := range nodes {
+		if n.err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+//sortRedisNodes sorts masters before replicas and then by address so that the
+//details are stable
+func sortRedisNodes(nodes []*redisNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].replica != nodes[j].replica {
+			return !nodes[i].replica
+		}
+		return nodes[i].addr < nodes[j].addr
+	})
+}
+
+func valueOrZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
 func getValueFromPair(s, sep string) string {
 	if i := strings.Index(s, sep); i >= 0 {
 		return s[i+1:]
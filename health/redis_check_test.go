@@ -1,31 +1,136 @@
 package health
 
 import (
-	"os"
+	"bufio"
+	"fmt"
+	"io"
+	"net"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/go-redis/redis"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
+const fakeRedisInfo = `# Server
+redis_version:5.0.3
+redis_git_sha1:00000000
+redis_mode:standalone
+
+# Clients
+connected_clients:3
+
+# Memory
+used_memory:900
+maxmemory:1000
+
+# Replication
+role:master
+`
+
 var _ = Describe("Redis Health Checker", func() {
 	Describe("Check", func() {
-		if os.Getenv("TEST_REDIS") == "true" {
-			It("gets Redis version with a valid client and connection", func() {
-				r := redis.NewClient(&redis.Options{
-					Addr:     ":6379",
-					Password: "",
-					DB:       0,
-				})
-				c := RedisCheck{Name: "testRedis", Client: r}
-				d := c.Check()
-				Expect(d.Name).To(Equal("testRedis"))
+		It("gets Redis version and info with a valid client and connection", func() {
+			fr := newFakeRedis(fakeRedisInfo)
+			defer fr.Close()
+
+			c := RedisCheck{Name: "testRedis", Client: redis.NewClient(&redis.Options{Addr: fr.Addr()})}
+			d := c.Check()
+			Expect(d.Name).To(Equal("testRedis"))
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.Version).To(Equal("5.0.3"))
+			Expect(d.Revision).To(Equal("00000000"))
+			Expect(d.State.Details).To(Equal("role=master used_memory=900 maxmemory=1000 connected_clients=3"))
+		})
+
+		It("sets WARN when thresholds are crossed", func() {
+			fr := newFakeRedis(fakeRedisInfo)
+			defer fr.Close()
+
+			client := redis.NewClient(&redis.Options{Addr: fr.Addr()})
+			d := RedisCheck{Name: "test", Client: client, MaxMemoryUsage: 0.9, MaxConnectedClients: 2}.Check()
+			Expect(d.State.Status).To(Equal(WARN))
+			Expect(d.State.Details).To(ContainSubstring("Memory usage 0.90 reached the threshold 0.90"))
+			Expect(d.State.Details).To(ContainSubstring("Connected clients 3 exceeded the threshold 2"))
+
+			d = RedisCheck{Name: "test", Client: client, MaxMemoryUsage: 0.95, MaxConnectedClients: 3}.Check()
+			Expect(d.State.Status).To(Equal(OK))
+		})
+
+		It("accepts a generic redis.Cmdable", func() {
+			fr := newFakeRedis(fakeRedisInfo)
+			defer fr.Close()
+
+			var client redis.UniversalClient = redis.NewUniversalClient(&redis.UniversalOptions{Addrs: []string{fr.Addr()}})
+			d := RedisCheck{Name: "test", Client: client}.Check()
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.Version).To(Equal("5.0.3"))
+		})
+
+		It("sets CRIT when the server is down", func() {
+			c := RedisCheck{Name: "test", Client: redis.NewClient(&redis.Options{Addr: closedAddr()})}
+			d := c.Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+			Expect(d.State.Details).To(HavePrefix("Error querying Redis info: "))
+		})
+
+		Context("with a cluster client", func() {
+			It("sets WARN when a replica is down and CRIT when a master is down", func() {
+				master1 := newFakeRedis(fakeRedisInfo)
+				defer master1.Close()
+				replica1 := newFakeRedis(strings.Replace(fakeRedisInfo, "role:master", "role:slave", 1))
+				defer replica1.Close()
+				down := closedAddr()
+
+				master1.slots = [][]string{{master1.Addr(), replica1.Addr()}}
+				client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{master1.Addr()}})
+				d := RedisCheck{Name: "test", Client: client}.Check()
 				Expect(d.State.Status).To(Equal(OK))
-				Expect(d.Version).To(MatchRegexp(`\d+\.\d+.\d+`))
-				Expect(d.Revision).To(Equal("00000000"))
+				Expect(d.Version).To(Equal("5.0.3"))
+				Expect(d.State.Details).To(ContainSubstring(master1.Addr() + ": role=master"))
+				Expect(d.State.Details).To(ContainSubstring(replica1.Addr() + ": role=slave"))
+				client.Close()
+
+				master1.slots = [][]string{{master1.Addr(), replica1.Addr(), down}}
+				client = redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{master1.Addr()}})
+				d = RedisCheck{Name: "test", Client: client}.Check()
+				Expect(d.State.Status).To(Equal(WARN))
+				Expect(d.State.Details).To(ContainSubstring(down + ": replica is down"))
+				client.Close()
+
+				master1.slots = [][]string{{master1.Addr(), replica1.Addr()}, {down}}
+				client = redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{master1.Addr()}})
+				d = RedisCheck{Name: "test", Client: client}.Check()
+				Expect(d.State.Status).To(Equal(CRIT))
+				Expect(d.State.Details).To(ContainSubstring(down + ": master is down"))
+				client.Close()
 			})
-		}
+		})
+
+		Context("with a ring client", func() {
+			It("sets WARN when some shards are down and CRIT when all are down", func() {
+				shard := newFakeRedis(fakeRedisInfo)
+				defer shard.Close()
+				down := closedAddr()
+
+				client := redis.NewRing(&redis.RingOptions{Addrs: map[string]string{"a": shard.Addr()}})
+				d := RedisCheck{Name: "test", Client: client}.Check()
+				Expect(d.State.Status).To(Equal(OK))
+				client.Close()
+
+				client = redis.NewRing(&redis.RingOptions{Addrs: map[string]string{"a": shard.Addr(), "b": down}})
+				d = RedisCheck{Name: "test", Client: client}.Check()
+				Expect(d.State.Status).To(Equal(WARN))
+				client.Close()
+
+				client = redis.NewRing(&redis.RingOptions{Addrs: map[string]string{"b": down}})
+				d = RedisCheck{Name: "test", Client: client}.Check()
+				Expect(d.State.Status).To(Equal(CRIT))
+				client.Close()
+			})
+		})
 
 		It("shows unknown type of Client", func() {
 			c := RedisCheck{Name: "test"}
@@ -36,6 +141,16 @@ var _ = Describe("Redis Health Checker", func() {
 		})
 	})
 
+	Describe("parseRedisInfo", func() {
+		It("parses the key-value lines and skips the section headers", func() {
+			info := parseRedisInfo(fakeRedisInfo)
+			Expect(info["redis_version"]).To(Equal("5.0.3"))
+			Expect(info["role"]).To(Equal("master"))
+			Expect(info).NotTo(HaveKey("# Server"))
+			Expect(info).To(HaveLen(7))
+		})
+	})
+
 	Describe("getValueFromPair", func() {
 		It("gets the value after the separator", func() {
 			Expect(getValueFromPair(":value", ":")).To(Equal("value"))
@@ -65,3 +180,120 @@ var _ = Describe("Redis Health Checker", func() {
 		})
 	})
 })
+
+//fakeRedis is an in-process stand-in of a Redis server that answers INFO, PING
+//and CLUSTER SLOTS using the RESP protocol
+type fakeRedis struct {
+	ln   net.Listener
+	info string
+	//slots lists the node addresses, master first, for each slot range
+	slots [][]string
+}
+
+func newFakeRedis(info string) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	fr := &fakeRedis{ln: ln, info: info}
+	go fr.serve()
+	return fr
+}
+
+func (fr *fakeRedis) Addr() string {
+	return fr.ln.Addr().String()
+}
+
+func (fr *fakeRedis) Close() {
+	fr.ln.Close()
+}
+
+func (fr *fakeRedis) serve() {
+	for {
+		conn, err := fr.ln.Accept()
+		if err != nil {
+			return
+		}
+		go fr.handle(conn)
+	}
+}
+
+func (fr *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readRESPCommand(r)
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(strings.Join(args[:minInt(2, len(args))], " ")) {
+		case "INFO":
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(fr.info), fr.info)
+		case "PING":
+			fmt.Fprint(conn, "+PONG\r\n")
+		case "CLUSTER SLOTS":
+			fmt.Fprint(conn, fr.clusterSlots())
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+}
+
+func (fr *fakeRedis) clusterSlots() string {
+	s := fmt.Sprintf("*%d\r\n", len(fr.slots))
+	per := 16384 / len(fr.slots)
+	for i, nodes := range fr.slots {
+		end := (i+1)*per - 1
+		if i == len(fr.slots)-1 {
+			end = 16383
+		}
+		s += fmt.Sprintf("*%d\r\n:%d\r\n:%d\r\n", len(nodes)+2, i*per, end)
+		for _, addr := range nodes {
+			host, port, _ := net.SplitHostPort(addr)
+			s += fmt.Sprintf("*2\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(host), host, len(port), port)
+		}
+	}
+	return s
+}
+
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid command %q", line)
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+//closedAddr returns an address that nothing listens on
+func closedAddr() string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
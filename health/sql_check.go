@@ -1,15 +1,102 @@
 package health
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	DriverMySQL     = "mysql"
+	DriverPostgres  = "postgres"
+	DriverSQLite    = "sqlite"
+	DriverSQLServer = "sqlserver"
+	DriverOracle    = "oracle"
+)
+
+var (
+	//VersionQueries are the queries used to retrieve the database version for
+	//each known driver
+	VersionQueries = map[string]string{
+		DriverMySQL:     "SELECT version()",
+		DriverPostgres:  "SELECT version()",
+		DriverSQLite:    "SELECT sqlite_version()",
+		DriverSQLServer: "SELECT @@VERSION",
+		DriverOracle:    "SELECT banner FROM v$version WHERE ROWNUM = 1",
+	}
+
+	//driverPatterns maps substrings of driver type names, like "*mysql.MySQLDriver"
+	//or "*pq.Driver", to the known drivers
+	driverPatterns = []struct {
+		pattern string
+		driver  string
+	}{
+		{"mysql", DriverMySQL},
+		{"pq.", DriverPostgres},
+		{"pgx", DriverPostgres},
+		{"stdlib.", DriverPostgres},
+		{"postgres", DriverPostgres},
+		{"sqlite", DriverSQLite},
+		{"mssql", DriverSQLServer},
+		{"sqlserver", DriverSQLServer},
+		{"godror", DriverOracle},
+		{"oci8", DriverOracle},
+		{"go_ora", DriverOracle},
+		{"oracle", DriverOracle},
+	}
+)
+
+//sqlPoolStats keeps the pool statistics of the previous check, since the wait
+//statistics of sql.DBStats are totals since the pool was opened
+type sqlPoolStats struct {
+	mu       sync.Mutex
+	previous sql.DBStats
+}
+
 type SQLCheck struct {
 	Name string
 	Type string
 	DB   interface{}
+
+	//Driver is one of the Driver* constants. It is detected from the driver of
+	//the DB if it is empty.
+	Driver string
+	//ProbeQuery is run after pinging the database, such as "SELECT 1". An error
+	//from it sets the status to CRIT. It is not run if it is empty.
+	ProbeQuery string
+	//VersionQuery retrieves the database version. An error from it sets the
+	//status to WARN. If it is empty, the query for the driver in VersionQueries
+	//is used, and no version is retrieved for an unknown driver.
+	VersionQuery string
+	//Timeout limits the whole check when it is > 0
+	Timeout time.Duration
+
+	//MaxPoolSaturation sets WARN when the ratio of in-use connections to the max
+	//open connections reaches it, such as 0.9. It is ignored when it is <= 0 or
+	//when the max open connections is unlimited.
+	MaxPoolSaturation float64
+	//MaxWaitDuration sets WARN when the time blocked waiting for new connections
+	//since the previous check exceeds it. It is ignored when it is <= 0.
+	MaxWaitDuration time.Duration
+	//MaxWaitCount sets WARN when the number of connections waited for since the
+	//previous check exceeds it. It is ignored when it is <= 0.
+	MaxWaitCount int64
+
+	//stats is shared by the copies of a check created with NewSQLCheck. The waits
+	//are compared since the pool was opened without it.
+	stats *sqlPoolStats
+}
+
+//NewSQLCheck creates a SQLCheck that keeps the pool statistics of its previous
+//check, so that MaxWaitDuration and MaxWaitCount are compared with the waits
+//since then. Set the other options on the returned check.
+func NewSQLCheck(name, typ string, db interface{}) *SQLCheck {
+	return &SQLCheck{Name: name, Type: typ, DB: db, stats: &sqlPoolStats{}}
 }
 
 func (sc SQLCheck) Check() *DependencyInfo {
@@ -20,21 +107,50 @@ func (sc SQLCheck) Check() *DependencyInfo {
 
 	switch conn := sc.DB.(type) {
 	case *sql.DB:
+		ctx := context.Background()
+		if sc.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, sc.Timeout)
+			defer cancel()
+		}
+
+		var details []string
 		sTime := time.Now()
-		row := conn.QueryRow("SELECT version()")
+		if err = conn.PingContext(ctx); err != nil {
+			err = errors.New("Error pinging the database: " + err.Error())
+		} else if sc.ProbeQuery != "" {
+			if err = sc.probe(ctx, conn); err != nil {
+				err = errors.New("Error running the probe query: " + err.Error())
+			}
+		}
+		if err == nil {
+			if query := sc.versionQuery(conn); query != "" {
+				if er1 := conn.QueryRowContext(ctx, query).Scan(&version); er1 != nil {
+					state.Status = WARN
+					details = append(details, "Error retrieving version: "+er1.Error())
+				}
+			}
+		}
 		t = time.Since(sTime).Seconds()
 
-		if err := row.Scan(&version); err != nil {
-			state.Status = WARN
-			state.Details = "Error retrieving version: " + err.Error()
+		stats := conn.Stats()
+		previous := sc.swapStats(stats)
+		if err == nil {
+			details = append(details, sc.verifyStats(stats, previous, &state)...)
 		}
+		details = append(details, formatDBStats(stats))
+		state.Details = strings.Join(details, "; ")
 	default:
 		err = errors.New("Unknown type of DB connection")
 	}
 
 	if err != nil {
 		state.Status = CRIT
-		state.Details = err.Error()
+		if state.Details == "" {
+			state.Details = err.Error()
+		} else {
+			state.Details = err.Error() + "; " + state.Details
+		}
 	}
 	return &DependencyInfo{
 		Name:         sc.Name,
@@ -53,3 +169,84 @@ func (sc SQLCheck) GetName() string {
 func (sc SQLCheck) GetType() string {
 	return sc.Type
 }
+
+//probe runs the probe query and reads through its result so that the time of
+//the round trip is included
+func (sc SQLCheck) probe(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, sc.ProbeQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+//swapStats records the pool statistics of this check and returns those of the
+//previous check, or the zero statistics without the state of NewSQLCheck
+func (sc SQLCheck) swapStats(stats sql.DBStats) sql.DBStats {
+	if sc.stats == nil {
+		return sql.DBStats{}
+	}
+	sc.stats.mu.Lock()
+	defer sc.stats.mu.Unlock()
+	previous := sc.stats.previous
+	sc.stats.previous = stats
+	return previous
+}
+
+//versionQuery returns the query to retrieve the version of the database
+func (sc SQLCheck) versionQuery(db *sql.DB) string {
+	if sc.VersionQuery != "" {
+		return sc.VersionQuery
+	}
+	driver := sc.Driver
+	if driver == "" {
+		driver = DetectDriver(db)
+	}
+	return VersionQueries[driver]
+}
+
+//verifyStats checks the pool statistics against the thresholds. The waits are
+//compared since the previous statistics. It sets WARN on the state and returns
+//the reasons if any threshold is crossed.
+func (sc SQLCheck) verifyStats(stats, previous sql.DBStats, state *DependencyState) []string {
+	var reasons []string
+	if sc.MaxPoolSaturation > 0 && stats.MaxOpenConnections > 0 {
+		saturation := float64(stats.InUse) / float64(stats.MaxOpenConnections)
+		if saturation >= sc.MaxPoolSaturation {
+			reasons = append(reasons, fmt.Sprintf("Connection pool saturation %.2f reached the threshold %.2f", saturation, sc.MaxPoolSaturation))
+		}
+	}
+	if wait := stats.WaitDuration - previous.WaitDuration; sc.MaxWaitDuration > 0 && wait > sc.MaxWaitDuration {
+		reasons = append(reasons, fmt.Sprintf("Connection wait duration %s since the previous check exceeded the threshold %s", wait, sc.MaxWaitDuration))
+	}
+	if count := stats.WaitCount - previous.WaitCount; sc.MaxWaitCount > 0 && count > sc.MaxWaitCount {
+		reasons = append(reasons, fmt.Sprintf("Connection wait count %d since the previous check exceeded the threshold %d", count, sc.MaxWaitCount))
+	}
+	if len(reasons) > 0 && IsMoreCritical(WARN, state.Status) {
+		state.Status = WARN
+	}
+	return reasons
+}
+
+//DetectDriver returns one of the Driver* constants based on the type name of
+//the driver of db. It returns an empty string for an unknown driver.
+func DetectDriver(db *sql.DB) string {
+	if db == nil {
+		return ""
+	}
+	name := strings.ToLower(reflect.TypeOf(db.Driver()).String())
+	for _, p := range driverPatterns {
+		if strings.Contains(name, p.pattern) {
+			return p.driver
+		}
+	}
+	return ""
+}
+
+func formatDBStats(stats sql.DBStats) string {
+	return fmt.Sprintf("open=%d in_use=%d idle=%d max_open=%d wait_count=%d wait_duration=%s",
+		stats.OpenConnections, stats.InUse, stats.Idle, stats.MaxOpenConnections, stats.WaitCount, stats.WaitDuration)
+}
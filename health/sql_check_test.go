@@ -1,6 +1,13 @@
 package health
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -14,5 +21,223 @@ var _ = Describe("SQL", func() {
 			Expect(d.State.Status).To(Equal(CRIT))
 			Expect(d.State.Details).To(Equal("Unknown type of DB connection"))
 		})
+
+		It("pings an unknown driver without retrieving the version", func() {
+			fd := &fakeDriver{}
+			c := SQLCheck{Name: "test", DB: sql.OpenDB(fd)}
+			d := c.Check()
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.Version).To(BeEmpty())
+			Expect(d.State.Details).To(HavePrefix("open=1 in_use=0 idle=1"))
+			Expect(fd.queries()).To(BeEmpty())
+		})
+
+		It("uses the version query of the detected driver", func() {
+			fd := &sqliteFakeDriver{fakeDriver{version: "3.31.1"}}
+			c := SQLCheck{Name: "test", DB: sql.OpenDB(fd)}
+			d := c.Check()
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.Version).To(Equal("3.31.1"))
+			Expect(fd.queries()).To(Equal([]string{"SELECT sqlite_version()"}))
+		})
+
+		It("uses the custom probe and version queries", func() {
+			fd := &fakeDriver{version: "v1"}
+			c := SQLCheck{Name: "test", DB: sql.OpenDB(fd), ProbeQuery: "SELECT 1", VersionQuery: "SELECT v()"}
+			d := c.Check()
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.Version).To(Equal("v1"))
+			Expect(fd.queries()).To(Equal([]string{"SELECT 1", "SELECT v()"}))
+		})
+
+		It("sets CRIT when the ping fails", func() {
+			fd := &fakeDriver{pingErr: errors.New("refused")}
+			c := SQLCheck{Name: "test", DB: sql.OpenDB(fd), Driver: DriverMySQL}
+			d := c.Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+			Expect(d.State.Details).To(HavePrefix("Error pinging the database: refused; open="))
+			Expect(fd.queries()).To(BeEmpty())
+		})
+
+		It("sets CRIT when the probe query fails", func() {
+			fd := &fakeDriver{queryErr: errors.New("bad query")}
+			c := SQLCheck{Name: "test", DB: sql.OpenDB(fd), ProbeQuery: "SELECT 1"}
+			d := c.Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+			Expect(d.State.Details).To(HavePrefix("Error running the probe query: bad query"))
+		})
+
+		It("sets WARN when the version query fails", func() {
+			fd := &fakeDriver{queryErr: errors.New("no function")}
+			c := SQLCheck{Name: "test", DB: sql.OpenDB(fd), Driver: DriverPostgres}
+			d := c.Check()
+			Expect(d.State.Status).To(Equal(WARN))
+			Expect(d.State.Details).To(HavePrefix("Error retrieving version: no function; open="))
+			Expect(fd.queries()).To(Equal([]string{"SELECT version()"}))
+		})
+
+		It("sets WARN when the pool saturation threshold is reached", func() {
+			db := sql.OpenDB(&fakeDriver{})
+			db.SetMaxOpenConns(2)
+			conn, err := db.Conn(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			c := SQLCheck{Name: "test", DB: db, MaxPoolSaturation: 0.5}
+			d := c.Check()
+			Expect(d.State.Status).To(Equal(WARN))
+			Expect(d.State.Details).To(ContainSubstring("Connection pool saturation 0.50 reached the threshold 0.50"))
+			Expect(d.State.Details).To(ContainSubstring("in_use=1"))
+
+			c.MaxPoolSaturation = 0.9
+			Expect(c.Check().State.Status).To(Equal(OK))
+		})
+
+		It("sets WARN when the waits since the previous check exceed the thresholds", func() {
+			db := sql.OpenDB(&fakeDriver{})
+			db.SetMaxOpenConns(1)
+			c := NewSQLCheck("test", TypeInternal, db)
+			c.MaxWaitCount = 1
+			Expect(c.Check().State.Status).To(Equal(OK))
+
+			waitForConns(db, 2)
+			d := c.Check()
+			Expect(d.State.Status).To(Equal(WARN))
+			Expect(d.State.Details).To(ContainSubstring("Connection wait count 2 since the previous check exceeded the threshold 1"))
+
+			//The total stays above the threshold, but there were no new waits
+			d = c.Check()
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.State.Details).To(ContainSubstring("wait_count=2"))
+
+			waitForConns(db, 1)
+			Expect(c.Check().State.Status).To(Equal(OK))
+
+			//Another check of the same DB and name keeps its own statistics, and
+			//the waits are compared since the pool was opened without NewSQLCheck
+			other := NewSQLCheck("test", TypeInternal, db)
+			other.MaxWaitCount = 1
+			Expect(other.Check().State.Status).To(Equal(WARN))
+			Expect(SQLCheck{Name: "test", DB: db, MaxWaitCount: 1}.Check().State.Status).To(Equal(WARN))
+		})
+	})
+
+	Describe("DetectDriver", func() {
+		It("detects the driver from its type name", func() {
+			Expect(DetectDriver(sql.OpenDB(&sqliteFakeDriver{}))).To(Equal(DriverSQLite))
+			Expect(DetectDriver(sql.OpenDB(&fakeDriver{}))).To(BeEmpty())
+			Expect(DetectDriver(nil)).To(BeEmpty())
+		})
 	})
 })
+
+//waitForConns makes n goroutines wait for a connection of the db whose max open
+//connections is 1
+func waitForConns(db *sql.DB, n int) {
+	ctx := context.Background()
+	held, err := db.Conn(ctx)
+	Expect(err).NotTo(HaveOccurred())
+	start := db.Stats().WaitCount
+	done := make(chan struct{}, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			if conn, err := db.Conn(ctx); err == nil {
+				conn.Close()
+			}
+			done <- struct{}{}
+		}()
+	}
+	Eventually(func() int64 { return db.Stats().WaitCount - start }).Should(BeEquivalentTo(n))
+	held.Close()
+	for i := 0; i < n; i++ {
+		<-done
+	}
+}
+
+//fakeDriver is an in-process database driver that records the queries it runs
+//and returns the version as the only row
+type fakeDriver struct {
+	version  string
+	pingErr  error
+	queryErr error
+
+	mu  sync.Mutex
+	log []string
+}
+
+type sqliteFakeDriver struct {
+	fakeDriver
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+func (d *fakeDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *fakeDriver) Driver() driver.Driver {
+	return d
+}
+
+func (d *sqliteFakeDriver) Driver() driver.Driver {
+	return d
+}
+
+func (d *fakeDriver) queries() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.log
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	return c.d.pingErr
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.mu.Lock()
+	c.d.log = append(c.d.log, query)
+	c.d.mu.Unlock()
+	if c.d.queryErr != nil {
+		return nil, c.d.queryErr
+	}
+	return &fakeRows{values: []string{c.d.version}}, nil
+}
+
+type fakeRows struct {
+	values []string
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"version"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.values[0]
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,25 @@
+package health
+
+//stubCheck is the check of the tests. It runs check if it is set, and otherwise
+//reports status. Use a pointer to change the status between the checks. The check
+//field makes it non-comparable.
+type stubCheck struct {
+	name   string
+	status string
+	check  func() *DependencyInfo
+}
+
+func (sc stubCheck) Check() *DependencyInfo {
+	if sc.check != nil {
+		return sc.check()
+	}
+	return &DependencyInfo{Name: sc.name, Type: TypeService, State: DependencyState{Status: sc.status, Details: "stub " + sc.status}}
+}
+
+func (sc stubCheck) GetName() string {
+	return sc.name
+}
+
+func (sc stubCheck) GetType() string {
+	return TypeService
+}
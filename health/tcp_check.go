@@ -0,0 +1,66 @@
+package health
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+var (
+	//DefaultDialTimeout is used by TCPCheck and DNSCheck when their Timeout is not set
+	DefaultDialTimeout = 5 * time.Second
+)
+
+//TCPCheck checks that a TCP connection can be established to Address, like
+//"some.host:5432". It is CRIT when the connection fails.
+type TCPCheck struct {
+	Name    string
+	Type    string
+	Address string
+	//Timeout defaults to DefaultDialTimeout
+	Timeout time.Duration
+	//ResponseTime sets WARN or CRIT when connecting takes longer
+	ResponseTime DurationThresholds
+}
+
+func (tc TCPCheck) Check() *DependencyInfo {
+	state := DependencyState{Status: OK}
+	timeout := tc.Timeout
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+
+	sTime := time.Now()
+	conn, err := net.DialTimeout("tcp", tc.Address, timeout)
+	elapsed := time.Since(sTime)
+
+	if err != nil {
+		err = errors.New("Error connecting to `" + tc.Address + "`: " + err.Error())
+	} else {
+		conn.Close()
+		state.Details = "Connected to " + conn.RemoteAddr().String()
+		raiseStatus(&state, tc.ResponseTime.statusAbove(elapsed))
+		if state.Status != OK {
+			state.Details += " in " + elapsed.String()
+		}
+	}
+
+	if err != nil {
+		state.Status = CRIT
+		state.Details = err.Error()
+	}
+	return &DependencyInfo{
+		Name:         tc.Name,
+		Type:         tc.Type,
+		State:        state,
+		ResponseTime: elapsed.Seconds(),
+	}
+}
+
+func (tc TCPCheck) GetName() string {
+	return tc.Name
+}
+
+func (tc TCPCheck) GetType() string {
+	return tc.Type
+}
@@ -0,0 +1,41 @@
+package health
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TCP", func() {
+	Describe("Check", func() {
+		It("is OK when the address accepts connections", func() {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer ln.Close()
+
+			d := TCPCheck{Name: "test", Type: TypeInternal, Address: ln.Addr().String()}.Check()
+			Expect(d.Name).To(Equal("test"))
+			Expect(d.Type).To(Equal(TypeInternal))
+			Expect(d.State.Status).To(Equal(OK))
+			Expect(d.State.Details).To(Equal("Connected to " + ln.Addr().String()))
+		})
+
+		It("sets WARN when connecting is slower than the threshold", func() {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer ln.Close()
+
+			d := TCPCheck{Name: "test", Address: ln.Addr().String(), ResponseTime: DurationThresholds{Warn: time.Nanosecond}}.Check()
+			Expect(d.State.Status).To(Equal(WARN))
+		})
+
+		It("sets CRIT when the connection fails", func() {
+			addr := closedAddr()
+			d := TCPCheck{Name: "test", Address: addr, Timeout: time.Second}.Check()
+			Expect(d.State.Status).To(Equal(CRIT))
+			Expect(d.State.Details).To(HavePrefix("Error connecting to `" + addr + "`: "))
+		})
+	})
+})
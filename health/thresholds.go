@@ -0,0 +1,55 @@
+package health
+
+import (
+	"time"
+)
+
+//Thresholds are the levels of a measured value at which a check sets WARN or
+//CRIT. A level <= 0 is ignored.
+type Thresholds struct {
+	Warn float64
+	Crit float64
+}
+
+//statusAbove is for values that are worse when higher, like memory usage. The
+//status is WARN or CRIT when the value reaches the respective level.
+func (t Thresholds) statusAbove(v float64) string {
+	if t.Crit > 0 && v >= t.Crit {
+		return CRIT
+	}
+	if t.Warn > 0 && v >= t.Warn {
+		return WARN
+	}
+	return OK
+}
+
+//statusBelow is for values that are worse when lower, like free disk space. The
+//status is WARN or CRIT when the value drops to the respective level.
+func (t Thresholds) statusBelow(v float64) string {
+	if t.Crit > 0 && v <= t.Crit {
+		return CRIT
+	}
+	if t.Warn > 0 && v <= t.Warn {
+		return WARN
+	}
+	return OK
+}
+
+//DurationThresholds are the durations at which a check sets WARN or CRIT. A
+//duration <= 0 is ignored.
+type DurationThresholds struct {
+	Warn time.Duration
+	Crit time.Duration
+}
+
+//statusAbove returns WARN or CRIT when d reaches the respective duration
+func (t DurationThresholds) statusAbove(d time.Duration) string {
+	return Thresholds{Warn: float64(t.Warn), Crit: float64(t.Crit)}.statusAbove(float64(d))
+}
+
+//raiseStatus sets the status to the given status if it is more critical
+func raiseStatus(state *DependencyState, status string) {
+	if IsMoreCritical(status, state.Status) {
+		state.Status = status
+	}
+}
@@ -0,0 +1,42 @@
+package health
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Thresholds", func() {
+	Describe("statusAbove", func() {
+		It("returns the status of the highest level reached", func() {
+			t := Thresholds{Warn: 10, Crit: 20}
+			Expect(t.statusAbove(9)).To(Equal(OK))
+			Expect(t.statusAbove(10)).To(Equal(WARN))
+			Expect(t.statusAbove(20)).To(Equal(CRIT))
+
+			Expect(Thresholds{Crit: 20}.statusAbove(19)).To(Equal(OK))
+			Expect(Thresholds{}.statusAbove(100)).To(Equal(OK))
+		})
+	})
+
+	Describe("statusBelow", func() {
+		It("returns the status of the lowest level reached", func() {
+			t := Thresholds{Warn: 0.2, Crit: 0.1}
+			Expect(t.statusBelow(0.3)).To(Equal(OK))
+			Expect(t.statusBelow(0.2)).To(Equal(WARN))
+			Expect(t.statusBelow(0.05)).To(Equal(CRIT))
+
+			Expect(Thresholds{}.statusBelow(0)).To(Equal(OK))
+		})
+	})
+
+	Describe("DurationThresholds", func() {
+		It("returns the status of the longest duration reached", func() {
+			t := DurationThresholds{Warn: time.Second, Crit: time.Minute}
+			Expect(t.statusAbove(time.Millisecond)).To(Equal(OK))
+			Expect(t.statusAbove(time.Second)).To(Equal(WARN))
+			Expect(t.statusAbove(time.Hour)).To(Equal(CRIT))
+		})
+	})
+})
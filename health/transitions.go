@@ -0,0 +1,206 @@
+package health
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coupa/foundation-go/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+//TransitionHandler is called when the status of a dependency changes. info is
+//the result that has the new status.
+type TransitionHandler func(name, from, to string, info *DependencyInfo)
+
+//ObservationHandler is called with every observed result of a dependency
+type ObservationHandler func(info *DependencyInfo)
+
+//Transitions tracks the status of every dependency and calls the handlers when
+//it changes. The first result of a dependency sets its status without calling
+//the transition handlers. It is safe for concurrent use.
+type Transitions struct {
+	mu        sync.Mutex
+	statuses  map[string]string
+	handlers  []TransitionHandler
+	observers []ObservationHandler
+}
+
+func NewTransitions() *Transitions {
+	return &Transitions{statuses: map[string]string{}}
+}
+
+//OnChange adds a handler of the status transitions
+func (t *Transitions) OnChange(h TransitionHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handlers = append(t.handlers, h)
+}
+
+//OnObserve adds a handler of every observed result, like MetricsStatus
+func (t *Transitions) OnObserve(h ObservationHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.observers = append(t.observers, h)
+}
+
+//Observe records the result of a dependency, calls the observation handlers, and
+//calls the transition handlers if its status changed
+func (t *Transitions) Observe(di *DependencyInfo) {
+	if di == nil {
+		return
+	}
+	t.mu.Lock()
+	from, seen := t.statuses[di.Name]
+	to := di.State.Status
+	t.statuses[di.Name] = to
+	handlers := t.handlers
+	observers := t.observers
+	t.mu.Unlock()
+
+	for _, o := range observers {
+		o(di)
+	}
+	if !seen || from == to {
+		return
+	}
+	for _, h := range handlers {
+		h(di.Name, from, to, di)
+	}
+}
+
+//Status returns the last observed status of the dependency, or "" if it has not
+//been observed
+func (t *Transitions) Status(name string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.statuses[name]
+}
+
+//LogTransition is a TransitionHandler that logs the transition with the standard
+//logger. The level is error for CRIT, warning for WARN and info for OK.
+func LogTransition(name, from, to string, info *DependencyInfo) {
+	entry := logrus.WithFields(logrus.Fields{
+		"dependency":      name,
+		"previous_status": from,
+		"status":          to,
+		"details":         info.State.Details,
+	})
+	msg := fmt.Sprintf("Dependency `%s` changed from %s to %s", name, from, to)
+	switch to {
+	case CRIT:
+		entry.Error(msg)
+	case WARN:
+		entry.Warn(msg)
+	default:
+		entry.Info(msg)
+	}
+}
+
+//MetricsTransition is a TransitionHandler that increments the "health.transitions"
+//counter. The metrics client must be set up.
+func MetricsTransition(name, from, to string, info *DependencyInfo) {
+	metrics.Increment("health.transitions", map[string]string{"dependency": name, "from": from, "to": to})
+}
+
+//MetricsStatus is an ObservationHandler that sets the "health.status" gauge of
+//the dependency to its critical level, which is 1 for OK, 2 for WARN and 3 for
+//CRIT. The gauge is set for every result so that it is reported from the start
+//and after the metrics agent restarts. The metrics client must be set up.
+func MetricsStatus(info *DependencyInfo) {
+	metrics.Gauge("health.status", CriticalLevels[info.State.Status], map[string]string{"dependency": info.Name})
+}
+
+//TransitionEvent is the JSON payload that WebhookNotifier posts
+type TransitionEvent struct {
+	Name       string          `json:"name"`
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	Timestamp  time.Time       `json:"timestamp"`
+	Dependency *DependencyInfo `json:"dependency"`
+}
+
+//WebhookNotifier posts the status transitions to a URL. Use its Notify method
+//as a TransitionHandler.
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string
+	//Client defaults to a client with a 10-second timeout
+	Client *http.Client
+	//MaxRetries is the number of retries after the first attempt fails
+	MaxRetries int
+	//Backoff is the wait before the first retry and doubles for every retry. It
+	//defaults to 1 second.
+	Backoff time.Duration
+	//ErrorHandler is called with the error if the event still cannot be posted
+	//after the retries. The error is logged if it is nil.
+	ErrorHandler func(error)
+}
+
+//Notify posts the transition in the background so that the health check is not blocked
+func (wn *WebhookNotifier) Notify(name, from, to string, info *DependencyInfo) {
+	event := TransitionEvent{Name: name, From: from, To: to, Timestamp: time.Now().UTC(), Dependency: info}
+	go func() {
+		if err := wn.Send(event); err != nil {
+			if wn.ErrorHandler != nil {
+				wn.ErrorHandler(err)
+			} else {
+				logrus.WithField("dependency", name).Error(err.Error())
+			}
+		}
+	}()
+}
+
+//Send posts the event and retries with exponential backoff on errors and non-2xx responses
+func (wn *WebhookNotifier) Send(event TransitionEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.New("Error encoding the transition event: " + err.Error())
+	}
+	client := wn.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	backoff := wn.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err = wn.post(client, body); err == nil {
+			return nil
+		}
+		if attempt >= wn.MaxRetries {
+			return fmt.Errorf("Error posting the transition event after %d attempts: %v", attempt+1, err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (wn *WebhookNotifier) post(client *http.Client, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, wn.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range wn.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
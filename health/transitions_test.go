@@ -0,0 +1,166 @@
+package health
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coupa/foundation-go/metrics"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transitions", func() {
+	dep := func(name, status string) *DependencyInfo {
+		return &DependencyInfo{Name: name, State: DependencyState{Status: status, Details: "details of " + status}}
+	}
+
+	Describe("Observe", func() {
+		It("calls the handlers when the status changes", func() {
+			var events []string
+			t := NewTransitions()
+			t.OnChange(func(name, from, to string, info *DependencyInfo) {
+				events = append(events, name+": "+from+" -> "+to+" ("+info.State.Details+")")
+			})
+
+			for _, s := range []string{OK, OK, CRIT, CRIT, WARN, OK} {
+				t.Observe(dep("mysql", s))
+			}
+			t.Observe(dep("redis", CRIT))
+			t.Observe(nil)
+
+			Expect(events).To(Equal([]string{
+				"mysql: OK -> CRIT (details of CRIT)",
+				"mysql: CRIT -> WARN (details of WARN)",
+				"mysql: WARN -> OK (details of OK)",
+			}))
+			Expect(t.Status("redis")).To(Equal(CRIT))
+			Expect(t.Status("unknown")).To(BeEmpty())
+		})
+
+		It("calls the observation handlers with every result", func() {
+			var observed []string
+			t := NewTransitions()
+			t.OnObserve(func(info *DependencyInfo) {
+				observed = append(observed, info.Name+": "+info.State.Status)
+			})
+			t.Observe(dep("mysql", OK))
+			t.Observe(dep("mysql", OK))
+			t.Observe(dep("mysql", CRIT))
+			Expect(observed).To(Equal([]string{"mysql: OK", "mysql: OK", "mysql: CRIT"}))
+		})
+	})
+
+	Describe("LogTransition", func() {
+		It("logs the transition at the level of the new status", func() {
+			var buf bytes.Buffer
+			logrus.SetOutput(&buf)
+			logrus.SetFormatter(&logrus.JSONFormatter{})
+			defer logrus.SetOutput(ioutil.Discard)
+
+			LogTransition("mysql", OK, CRIT, dep("mysql", CRIT))
+			var data map[string]interface{}
+			Expect(json.Unmarshal(buf.Bytes(), &data)).To(Succeed())
+			Expect(data["level"]).To(Equal("error"))
+			Expect(data["msg"]).To(Equal("Dependency `mysql` changed from OK to CRIT"))
+			Expect(data["dependency"]).To(Equal("mysql"))
+			Expect(data["previous_status"]).To(Equal(OK))
+			Expect(data["status"]).To(Equal(CRIT))
+			Expect(data["details"]).To(Equal("details of CRIT"))
+		})
+	})
+
+	Describe("MetricsTransition and MetricsStatus", func() {
+		It("emit the transition counter and the status gauge", func() {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+			metrics.Set(metrics.NewStatsd(conn.LocalAddr().String(), "", "v1", "app", 1))
+			defer metrics.Set(nil)
+
+			//The gauge is set by the first observation, which is not a transition
+			t := NewTransitions()
+			t.OnChange(MetricsTransition)
+			t.OnObserve(MetricsStatus)
+			t.Observe(dep("redis", OK))
+			t.Observe(dep("mysql", OK))
+			t.Observe(dep("mysql", CRIT))
+			metrics.Flush()
+
+			//The client may send an empty packet when it connects
+			packet := ""
+			buf := make([]byte, 1024)
+			for packet == "" {
+				conn.SetReadDeadline(time.Now().Add(time.Second))
+				n, _, err := conn.ReadFrom(buf)
+				Expect(err).NotTo(HaveOccurred())
+				packet = string(buf[:n])
+			}
+			Expect(packet).To(ContainSubstring("name=health.transitions"))
+			Expect(packet).To(ContainSubstring("to=CRIT"))
+			Expect(strings.Count(packet, "name=health.transitions")).To(Equal(1))
+			Expect(packet).To(MatchRegexp(`gauges,.*dependency=redis.*name=health.status.*:1\|g`))
+			Expect(packet).To(MatchRegexp(`gauges,.*dependency=mysql.*name=health.status.*:3\|g`))
+		})
+	})
+
+	Describe("WebhookNotifier", func() {
+		It("posts the event and retries on failures", func() {
+			var mu sync.Mutex
+			var attempts int
+			var received TransitionEvent
+			var header http.Header
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				attempts++
+				if attempts < 3 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				header = r.Header
+				json.NewDecoder(r.Body).Decode(&received)
+			}))
+			defer ts.Close()
+
+			wn := &WebhookNotifier{URL: ts.URL, Headers: map[string]string{"X-Token": "secret"}, MaxRetries: 2, Backoff: time.Millisecond}
+			wn.Notify("mysql", OK, CRIT, dep("mysql", CRIT))
+
+			Eventually(func() string {
+				mu.Lock()
+				defer mu.Unlock()
+				return received.To
+			}).Should(Equal(CRIT))
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(attempts).To(Equal(3))
+			Expect(header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(header.Get("X-Token")).To(Equal("secret"))
+			Expect(received.Name).To(Equal("mysql"))
+			Expect(received.From).To(Equal(OK))
+			Expect(received.Dependency.State.Details).To(Equal("details of CRIT"))
+		})
+
+		It("fails after the retries", func() {
+			attempts := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(http.StatusInternalServerError)
+			}))
+			defer ts.Close()
+
+			wn := &WebhookNotifier{URL: ts.URL, MaxRetries: 1, Backoff: time.Millisecond}
+			err := wn.Send(TransitionEvent{Name: "mysql"})
+			Expect(err).To(MatchError("Error posting the transition event after 2 attempts: Unexpected response status 500"))
+			Expect(attempts).To(Equal(2))
+		})
+	})
+})
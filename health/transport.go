@@ -0,0 +1,36 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+//TransportOptions configure the HTTP requests to the health endpoints of other
+//services. They are shared by WebCheck and the health client package.
+type TransportOptions struct {
+	//Timeout limits the whole request. There is no timeout if it is 0.
+	Timeout time.Duration
+	//Headers are added to every request, such as an authorization header
+	Headers map[string]string
+	//Transport makes the requests. It defaults to http.DefaultTransport. Set it
+	//to a transport with a TLS config for custom certificates.
+	Transport http.RoundTripper
+}
+
+//HTTPClient returns a client with the transport and timeout of the options
+func (o TransportOptions) HTTPClient() *http.Client {
+	return &http.Client{Timeout: o.Timeout, Transport: o.Transport}
+}
+
+//Get sends a GET request with the headers of the options
+func (o TransportOptions) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range o.Headers {
+		req.Header.Set(k, v)
+	}
+	return o.HTTPClient().Do(req.WithContext(ctx))
+}
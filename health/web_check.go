@@ -1,7 +1,7 @@
 package health
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -19,16 +19,27 @@ type WebCheck struct {
 	//This is mainly for checking some web server that does not implement a proper
 	//health endpoint and you have to compromise the approach to check it.
 	ExpectedStatusCode int
+	//DefaultStatus is used when the response body does not have a status or has
+	//a status that is not one of OK, WARN, or CRIT. If it is empty, the default
+	//is OK for third-party type and WARN for the other types.
+	DefaultStatus string
+	//TransportOptions set the timeout, headers and transport of the request. It
+	//is a pointer so that WebCheck stays comparable.
+	TransportOptions *TransportOptions
 }
 
 func (wc WebCheck) Check() *DependencyInfo {
 	var err error
 	var t float64
-	var ver, rev string
+	var hr *DetailedHealth
 
 	state := DependencyState{Status: OK}
+	opts := TransportOptions{}
+	if wc.TransportOptions != nil {
+		opts = *wc.TransportOptions
+	}
 	sTime := time.Now()
-	resp, err := http.Get(wc.URL)
+	resp, err := opts.Get(context.Background(), wc.URL)
 	t = time.Since(sTime).Seconds()
 
 	if err != nil {
@@ -38,7 +49,7 @@ func (wc WebCheck) Check() *DependencyInfo {
 		code := resp.StatusCode
 		if code < 300 {
 			defer resp.Body.Close()
-			ver, rev = wc.parseBody(resp, &state)
+			hr = wc.parseBody(resp, &state)
 		} else if code < 400 {
 			//3xx redirect code. Set WARN
 			state.Status = WARN
@@ -49,14 +60,18 @@ func (wc WebCheck) Check() *DependencyInfo {
 		}
 		wc.verifyStatusCode(code, &state)
 	}
-	return &DependencyInfo{
+	di := &DependencyInfo{
 		Name:         wc.Name,
 		Type:         wc.Type,
-		Version:      ver,
-		Revision:     rev,
 		State:        state,
 		ResponseTime: t,
 	}
+	if hr != nil {
+		di.Version = hr.Version
+		di.Revision = hr.Revision
+		di.Dependencies = summarizeDependencies(hr.Dependencies)
+	}
+	return di
 }
 
 func (wc WebCheck) GetName() string {
@@ -68,8 +83,8 @@ func (wc WebCheck) GetType() string {
 }
 
 //parseBody checks the response body and set the DependencyState data. It returns
-//the version and revision if it is able to find it from the response body.
-func (wc WebCheck) parseBody(resp *http.Response, state *DependencyState) (string, string) {
+//the parsed health document if the response body is a valid health JSON object.
+func (wc WebCheck) parseBody(resp *http.Response, state *DependencyState) *DetailedHealth {
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		if wc.Type != TypeThirdParty {
@@ -78,20 +93,62 @@ func (wc WebCheck) parseBody(resp *http.Response, state *DependencyState) (strin
 			state.Status = WARN
 		}
 		state.Details = "Unable to read the response body: " + err.Error()
-		return "", ""
+		return nil
 	}
-	var jsonData map[string]string
-	if err = json.Unmarshal(data, &jsonData); err != nil {
+	hr, err := ParseHealth(data)
+	if err != nil {
 		if wc.Type != TypeThirdParty {
 			//When the server type is "internal" or "service", assume that it would
 			//follow the microservice standard, so WARN on error
 			state.Status = WARN
 		}
 		state.Details = "Response body is not a key-value JSON object: " + err.Error()
-		return "", ""
+		return nil
+	}
+	if status := NormalizeStatus(hr.Status); status != "" {
+		state.Status = status
+	} else {
+		state.Status = wc.defaultStatus()
+		if hr.Status == "" {
+			state.Details = "Response body has no status"
+		} else {
+			state.Details = "Response body has unknown status `" + hr.Status + "`"
+		}
+	}
+	return hr
+}
+
+//defaultStatus is the status used when the response body has no valid status
+func (wc WebCheck) defaultStatus() string {
+	if status := NormalizeStatus(wc.DefaultStatus); status != "" {
+		return status
+	}
+	if wc.Type == TypeThirdParty {
+		return OK
+	}
+	return WARN
+}
+
+//summarizeDependencies counts the statuses of the dependencies of a dependency.
+//It returns nil if there is no dependency, such as for a simple health.
+func summarizeDependencies(deps []DependencyInfo) *DependencySummary {
+	if len(deps) == 0 {
+		return nil
+	}
+	summary := &DependencySummary{Total: len(deps)}
+	for _, d := range deps {
+		switch NormalizeStatus(d.State.Status) {
+		case OK:
+			summary.OK++
+		case WARN:
+			summary.WARN++
+		case CRIT:
+			summary.CRIT++
+		default:
+			summary.Unknown++
+		}
 	}
-	state.Status = jsonData["status"]
-	return jsonData["version"], jsonData["revision"]
+	return summary
 }
 
 //verifyStatusCode checks if ExpectedStatusCode
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -14,10 +15,10 @@ var _ = Describe("WebService", func() {
 	Describe("Check", func() {
 		It("returns the data from the service health check", func() {
 			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				h := Health{
-					"status":   WARN,
-					"version":  "fakeVer",
-					"revision": "fakeRev",
+				h := SimpleHealth{
+					Status:   WARN,
+					Version:  "fakeVer",
+					Revision: "fakeRev",
 				}
 				d, _ := json.Marshal(h)
 				fmt.Fprint(w, string(d))
@@ -32,9 +33,9 @@ var _ = Describe("WebService", func() {
 		})
 
 		Context("service returns empty data", func() {
-			It("returns empty fields", func() {
+			It("returns empty fields and the default status", func() {
 				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					h := Health{}
+					h := map[string]interface{}{}
 					d, _ := json.Marshal(h)
 					fmt.Fprint(w, string(d))
 				}))
@@ -44,16 +45,70 @@ var _ = Describe("WebService", func() {
 				Expect(d.Name).To(Equal("test"))
 				Expect(d.Version).To(Equal(""))
 				Expect(d.Revision).To(Equal(""))
-				Expect(d.State.Status).To(Equal(""))
+				Expect(d.State.Status).To(Equal(WARN))
+				Expect(d.State.Details).To(Equal("Response body has no status"))
+
+				d = WebCheck{Name: "test", URL: ts.URL, Type: TypeThirdParty}.Check()
+				Expect(d.State.Status).To(Equal(OK))
+
+				d = WebCheck{Name: "test", URL: ts.URL, Type: TypeService, DefaultStatus: "crit"}.Check()
+				Expect(d.State.Status).To(Equal(CRIT))
+			})
+		})
+
+		Context("service returns the detailed health", func() {
+			It("parses non-string fields and summarizes the dependencies", func() {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					fmt.Fprint(w, `{
+						"status": "ok",
+						"version": "fakeVer",
+						"revision": "fakeRev",
+						"uptime": 1234,
+						"extra": {"some": ["thing"]},
+						"project": {"repo": "https://some.repo", "owners": ["owner1"]},
+						"dependencies": [
+							{"name": "a", "state": {"status": "OK"}},
+							{"name": "b", "state": {"status": "warn"}},
+							{"name": "c", "state": {"status": "CRIT", "details": "down"}},
+							{"name": "d", "state": {}}
+						]
+					}`)
+				}))
+				defer ts.Close()
+
+				d := WebCheck{Name: "test", URL: ts.URL, Type: TypeService}.Check()
+				Expect(d.State.Status).To(Equal(OK))
+				Expect(d.State.Details).To(BeEmpty())
+				Expect(d.Version).To(Equal("fakeVer"))
+				Expect(d.Revision).To(Equal("fakeRev"))
+				Expect(d.Dependencies).To(Equal(&DependencySummary{Total: 4, OK: 1, WARN: 1, CRIT: 1, Unknown: 1}))
+			})
+		})
+
+		Context("service returns an unknown status", func() {
+			It("sets the default status", func() {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					fmt.Fprint(w, `{"status": "healthy", "version": "fakeVer"}`)
+				}))
+				defer ts.Close()
+
+				d := WebCheck{Name: "test", URL: ts.URL, Type: TypeService}.Check()
+				Expect(d.State.Status).To(Equal(WARN))
+				Expect(d.State.Details).To(Equal("Response body has unknown status `healthy`"))
+				Expect(d.Version).To(Equal("fakeVer"))
+				Expect(d.Dependencies).To(BeNil())
+
+				d = WebCheck{Name: "test", URL: ts.URL, Type: TypeService, DefaultStatus: OK}.Check()
+				Expect(d.State.Status).To(Equal(OK))
 			})
 		})
 
 		It("sets WARN for redirect response", func() {
 			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				h := Health{
-					"status":   WARN,
-					"version":  "fakeVer",
-					"revision": "fakeRev",
+				h := SimpleHealth{
+					Status:   WARN,
+					Version:  "fakeVer",
+					Revision: "fakeRev",
 				}
 				w.WriteHeader(399)
 				d, _ := json.Marshal(h)
@@ -70,10 +125,10 @@ var _ = Describe("WebService", func() {
 
 		It("sets CRIT for error response", func() {
 			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				h := Health{
-					"status":   WARN,
-					"version":  "fakeVer",
-					"revision": "fakeRev",
+				h := SimpleHealth{
+					Status:   WARN,
+					Version:  "fakeVer",
+					Revision: "fakeRev",
 				}
 				w.WriteHeader(404)
 				d, _ := json.Marshal(h)
@@ -121,10 +176,10 @@ var _ = Describe("WebService", func() {
 			Context("and the status code matches", func() {
 				It("sets OK regardless of the status in the response body", func() {
 					ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-						h := Health{
-							"status":   WARN,
-							"version":  "fakeVer",
-							"revision": "fakeRev",
+						h := SimpleHealth{
+							Status:   WARN,
+							Version:  "fakeVer",
+							Revision: "fakeRev",
 						}
 						w.WriteHeader(200)
 						d, _ := json.Marshal(h)
@@ -141,10 +196,10 @@ var _ = Describe("WebService", func() {
 
 				It("sets OK as long as the status code matches", func() {
 					ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-						h := Health{
-							"status":   WARN,
-							"version":  "fakeVer",
-							"revision": "fakeRev",
+						h := SimpleHealth{
+							Status:   WARN,
+							Version:  "fakeVer",
+							Revision: "fakeRev",
 						}
 						w.WriteHeader(400)
 						d, _ := json.Marshal(h)
@@ -183,10 +238,10 @@ var _ = Describe("WebService", func() {
 			Context("and the status code does not match", func() {
 				It("sets CRIT regardless of the status in the response body", func() {
 					ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-						h := Health{
-							"status":   WARN,
-							"version":  "fakeVer",
-							"revision": "fakeRev",
+						h := SimpleHealth{
+							Status:   WARN,
+							Version:  "fakeVer",
+							Revision: "fakeRev",
 						}
 						w.WriteHeader(200)
 						d, _ := json.Marshal(h)
@@ -225,6 +280,34 @@ var _ = Describe("WebService", func() {
 			})
 		})
 
+		Context("with TransportOptions", func() {
+			It("sends the headers and applies the timeout", func() {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get("Authorization") != "Bearer token" {
+						w.WriteHeader(401)
+						return
+					}
+					if r.URL.Query().Get("slow") != "" {
+						time.Sleep(100 * time.Millisecond)
+					}
+					fmt.Fprint(w, `{"status":"OK"}`)
+				}))
+				defer ts.Close()
+
+				opts := &TransportOptions{Headers: map[string]string{"Authorization": "Bearer token"}}
+				d := WebCheck{Name: "test", URL: ts.URL, Type: TypeService, TransportOptions: opts}.Check()
+				Expect(d.State.Status).To(Equal(OK))
+
+				d = WebCheck{Name: "test", URL: ts.URL, Type: TypeService}.Check()
+				Expect(d.State.Status).To(Equal(CRIT))
+
+				opts.Timeout = 10 * time.Millisecond
+				d = WebCheck{Name: "test", URL: ts.URL + "?slow=1", Type: TypeService, TransportOptions: opts}.Check()
+				Expect(d.State.Status).To(Equal(CRIT))
+				Expect(d.State.Details).To(HavePrefix("Error connecting to"))
+			})
+		})
+
 		Context("when error", func() {
 			It("returns error connection as details", func() {
 				d := WebCheck{Name: "test", URL: "abc", Type: TypeService}.Check()
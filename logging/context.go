@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+//ContextKey is the key of the request logger in the keys of a *gin.Context. Set
+//it with c.Set(logging.ContextKey, logging.LoggerValue(ctx)).
+const ContextKey = "foundation.logging.logger"
+
+//contextKey is the type of the key of the request logger in a context, so that it
+//does not collide with the keys of other packages
+type contextKey struct{}
+
+var loggerKey = contextKey{}
+
+const (
+	correlationIDField = "correlation_id"
+	userIDField        = "user_id"
+)
+
+//contextLogger is the request-scoped logger. The fields added with AddFields are
+//seen by everyone holding the context, including the request logger middleware.
+type contextLogger struct {
+	mu    sync.RWMutex
+	entry *logrus.Entry
+}
+
+//NewContext returns a copy of ctx with the logger. Use FromContext to get it back.
+func NewContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	if entry == nil {
+		entry = logrus.NewEntry(logrus.StandardLogger())
+	}
+	return context.WithValue(ctx, loggerKey, &contextLogger{entry: entry})
+}
+
+//LoggerValue returns the request logger of ctx to store under ContextKey in the
+//keys of a *gin.Context, or nil if ctx has no logger
+func LoggerValue(ctx context.Context) interface{} {
+	if cl := loggerOf(ctx); cl != nil {
+		return cl
+	}
+	return nil
+}
+
+//NewRequestContext returns a copy of ctx with a logger of the standard logger that
+//has the correlation ID and the user ID of the request if they are not empty.
+//middleware.Correlation calls it for every request.
+func NewRequestContext(ctx context.Context, correlationID, userID string) context.Context {
+	fields := logrus.Fields{}
+	if correlationID != "" {
+		fields[correlationIDField] = correlationID
+	}
+	if userID != "" {
+		fields[userIDField] = userID
+	}
+	return NewContext(ctx, logrus.WithFields(fields))
+}
+
+//FromContext returns the request logger of ctx, which has the correlation ID
+//and the fields added with AddFields. It returns an entry of the standard logger
+//if ctx has no logger, so it is always safe to use.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if cl := loggerOf(ctx); cl != nil {
+		cl.mu.RLock()
+		defer cl.mu.RUnlock()
+		return cl.entry
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
+
+//AddFields adds the fields, like the tenant or the order ID, to the logger of ctx
+//so that the later log lines of the request include them. It does nothing if
+//ctx has no logger.
+func AddFields(ctx context.Context, fields logrus.Fields) {
+	if cl := loggerOf(ctx); cl != nil {
+		cl.mu.Lock()
+		defer cl.mu.Unlock()
+		cl.entry = cl.entry.WithFields(fields)
+	}
+}
+
+//AddField adds a field to the logger of ctx. See AddFields.
+func AddField(ctx context.Context, key string, value interface{}) {
+	AddFields(ctx, logrus.Fields{key: value})
+}
+
+//CorrelationID returns the correlation ID of the request logger of ctx
+func CorrelationID(ctx context.Context) string {
+	return stringField(ctx, correlationIDField)
+}
+
+//UserID returns the user ID of the request logger of ctx
+func UserID(ctx context.Context) string {
+	return stringField(ctx, userIDField)
+}
+
+func stringField(ctx context.Context, key string) string {
+	if cl := loggerOf(ctx); cl != nil {
+		cl.mu.RLock()
+		defer cl.mu.RUnlock()
+		if v, yes := cl.entry.Data[key].(string); yes {
+			return v
+		}
+	}
+	return ""
+}
+
+func loggerOf(ctx context.Context) *contextLogger {
+	if ctx == nil {
+		return nil
+	}
+	if cl, yes := ctx.Value(loggerKey).(*contextLogger); yes {
+		return cl
+	}
+	//A *gin.Context has the logger in its keys
+	cl, _ := ctx.Value(ContextKey).(*contextLogger)
+	return cl
+}
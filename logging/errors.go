@@ -0,0 +1,193 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	//ChainSuffix is the suffix of the field of the messages of the wrapped errors,
+	//like "error_chain"
+	ChainSuffix = "_chain"
+	//StackTraceSuffix is the suffix of the field of the stack trace of an error,
+	//like "error_stacktrace"
+	StackTraceSuffix = "_stacktrace"
+	//FileField is the field of the file and the line of the caller
+	FileField = "file"
+	//FuncField is the field of the function of the caller
+	FuncField = "func"
+)
+
+var (
+	logrusPackage  = reflect.TypeOf(logrus.Entry{}).PkgPath() + "."
+	loggingPackage = reflect.TypeOf(CustomJSONFormatter{}).PkgPath() + "."
+)
+
+//StackTracer is an error that carries the stack trace of where it was created,
+//like the errors of WithStack. The errors of github.com/pkg/errors, whose
+//StackTrace method returns the frames, are supported too.
+type StackTracer interface {
+	StackTrace() string
+}
+
+//stackError is an error with the stack trace of where it was created
+type stackError struct {
+	error
+	stack []uintptr
+}
+
+//WithStack returns the error with the stack trace of the caller. It returns nil
+//if err is nil.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	return &stackError{error: err, stack: pcs[:n]}
+}
+
+func (e *stackError) Unwrap() error {
+	return e.error
+}
+
+func (e *stackError) StackTrace() string {
+	var sb strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		f, more := frames.Next()
+		sb.WriteString(f.Function + "\n\t" + f.File + ":" + strconv.Itoa(f.Line) + "\n")
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+//ErrorFields returns the fields of an error under the key, like "error". They are
+//the message of the error, the messages of the errors it wraps under
+//"<key>_chain", and the stack trace of the innermost error that carries one under
+//"<key>_stacktrace". A nil error, including a nil pointer, is rendered as null.
+func ErrorFields(key string, err error) logrus.Fields {
+	if isNil(err) {
+		return logrus.Fields{key: nil}
+	}
+	fields := logrus.Fields{key: messageOf(err)}
+	chain := errorChain(err)
+	if len(chain) > 1 {
+		messages := make([]string, len(chain))
+		for i, e := range chain {
+			messages[i] = messageOf(e)
+		}
+		fields[key+ChainSuffix] = messages
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if stack := stackTraceOf(chain[i]); stack != "" {
+			fields[key+StackTraceSuffix] = stack
+			break
+		}
+	}
+	return fields
+}
+
+//PanicFields returns the fields of a recovered panic in the same structure as
+//ErrorFields under "error", with the stack trace of the panic. Call it in the
+//deferred function that recovers.
+func PanicFields(r interface{}) logrus.Fields {
+	err, yes := r.(error)
+	if !yes {
+		err = errors.New(fmt.Sprint(r))
+	}
+	fields := ErrorFields(logrus.ErrorKey, err)
+	fields[logrus.ErrorKey+StackTraceSuffix] = string(debug.Stack())
+	return fields
+}
+
+//isNil returns whether the error is nil or a nil pointer, whose methods may panic
+func isNil(err error) bool {
+	if err == nil {
+		return true
+	}
+	v := reflect.ValueOf(err)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+//messageOf returns the message of the error. A panic of its Error method is
+//rendered like fmt does.
+func messageOf(err error) (message string) {
+	defer func() {
+		if r := recover(); r != nil {
+			message = fmt.Sprintf("%%!v(PANIC=Error method: %v)", r)
+		}
+	}()
+	return err.Error()
+}
+
+//errorChain returns the error and the errors it wraps, depth first. The wrapped
+//errors are skipped if Unwrap panics.
+func errorChain(err error) (chain []error) {
+	chain = []error{err}
+	defer func() {
+		if r := recover(); r != nil {
+			chain = chain[:1]
+		}
+	}()
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if wrapped := e.Unwrap(); !isNil(wrapped) {
+			chain = append(chain, errorChain(wrapped)...)
+		}
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range e.Unwrap() {
+			if !isNil(wrapped) {
+				chain = append(chain, errorChain(wrapped)...)
+			}
+		}
+	}
+	return chain
+}
+
+//stackTraceOf returns the stack trace that the error carries, or "" if none or
+//if getting it panics
+func stackTraceOf(err error) (stack string) {
+	defer func() {
+		if recover() != nil {
+			stack = ""
+		}
+	}()
+	if st, yes := err.(StackTracer); yes {
+		return st.StackTrace()
+	}
+	//Like github.com/pkg/errors, whose stack trace is printed with %+v
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return ""
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%+v", method.Call(nil)[0].Interface()), "\n")
+}
+
+//callerOf returns the frame that logged the entry outside of logrus and this package
+func callerOf(entry *logrus.Entry) *runtime.Frame {
+	if entry.HasCaller() {
+		return entry.Caller
+	}
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, logrusPackage) && !strings.HasPrefix(f.Function, loggingPackage) {
+			return &f
+		}
+		if !more {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package logging_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	. "github.com/coupa/foundation-go/logging"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Errors", func() {
+	Describe("ErrorFields", func() {
+		It("has the message and the wrapped errors", func() {
+			inner := errors.New("connection refused")
+			err := fmt.Errorf("Error saving the order: %w", fmt.Errorf("Error connecting: %w", inner))
+			fields := ErrorFields("error", err)
+			Expect(fields["error"]).To(Equal("Error saving the order: Error connecting: connection refused"))
+			Expect(fields["error_chain"]).To(Equal([]string{
+				"Error saving the order: Error connecting: connection refused",
+				"Error connecting: connection refused",
+				"connection refused",
+			}))
+			Expect(fields).NotTo(HaveKey("error_stacktrace"))
+
+			Expect(ErrorFields("error", inner)).To(Equal(logrus.Fields{"error": "connection refused"}))
+			Expect(ErrorFields("cause", multiError{inner, errors.New("timeout")})["cause_chain"]).To(Equal([]string{"2 errors", "connection refused", "timeout"}))
+		})
+
+		It("has the stack trace of the error", func() {
+			err := fmt.Errorf("Error saving the order: %w", WithStack(errors.New("connection refused")))
+			fields := ErrorFields("error", err)
+			Expect(fields["error"]).To(Equal("Error saving the order: connection refused"))
+			Expect(fields["error_stacktrace"]).To(ContainSubstring("errors_test.go:"))
+
+			Expect(ErrorFields("error", tracedError{})["error_stacktrace"]).To(Equal("main.main\n\tmain.go:1"))
+			Expect(WithStack(nil)).To(BeNil())
+		})
+
+		It("renders a nil pointer error as null and survives the panics of the error methods", func() {
+			var nilErr *valueError
+			Expect(ErrorFields("error", nilErr)).To(Equal(logrus.Fields{"error": nil}))
+			//A wrapped nil pointer is not in the chain
+			Expect(ErrorFields("error", fmt.Errorf("Error saving: %w", nilErr))).To(Equal(logrus.Fields{"error": "Error saving: <nil>"}))
+
+			fields := ErrorFields("error", panicError{})
+			Expect(fields["error"]).To(Equal("%!v(PANIC=Error method: boom)"))
+			Expect(fields).NotTo(HaveKey("error_stacktrace"))
+		})
+	})
+
+	Describe("PanicFields", func() {
+		It("has the panic and its stack trace", func() {
+			var fields logrus.Fields
+			func() {
+				defer func() {
+					fields = PanicFields(recover())
+				}()
+				panic("boom")
+			}()
+			Expect(fields["error"]).To(Equal("boom"))
+			Expect(fields["error_stacktrace"]).To(ContainSubstring("errors_test.go:"))
+		})
+	})
+
+	Describe("CustomJSONFormatter", func() {
+		var (
+			logger *logrus.Logger
+			hook   *test.Hook
+		)
+
+		BeforeEach(func() {
+			logger, hook = test.NewNullLogger()
+		})
+
+		AfterEach(func() {
+			InitStandardLogger("")
+		})
+
+		format := func(entry *logrus.Entry) map[string]interface{} {
+			data, err := logger.Formatter.Format(entry)
+			Expect(err).NotTo(HaveOccurred())
+			var m map[string]interface{}
+			Expect(json.Unmarshal(data, &m)).To(Succeed())
+			return m
+		}
+
+		It("renders the errors with their chains", func() {
+			InitLogger("v1", logger)
+			logger.WithError(fmt.Errorf("Error saving: %w", errors.New("refused"))).Error("failed")
+			m := format(hook.LastEntry())
+			Expect(m["error"]).To(Equal("Error saving: refused"))
+			Expect(m["error_chain"]).To(Equal([]interface{}{"Error saving: refused", "refused"}))
+			Expect(m).NotTo(HaveKey("file"))
+		})
+
+		It("renders a nil pointer error as null", func() {
+			InitLogger("v1", logger)
+			var nilErr *valueError
+			logger.WithError(nilErr).Error("failed")
+			m := format(hook.LastEntry())
+			Expect(m).To(HaveKeyWithValue("error", BeNil()))
+			Expect(m["message"]).To(Equal("failed"))
+		})
+
+		It("adds the caller of the WARN and above entries", func() {
+			Expect(InitLoggerWithConfig("v1", logger, Config{ReportCaller: true})).To(Succeed())
+			logger.Info("info")
+			Expect(format(hook.LastEntry())).NotTo(HaveKey("file"))
+
+			entry := logger.WithField("a", 1)
+			entry.Level = logrus.WarnLevel
+			m := format(entry)
+			Expect(m["file"]).To(ContainSubstring("errors_test.go:"))
+			Expect(m["func"]).To(ContainSubstring("logging_test"))
+		})
+	})
+})
+
+//multiError wraps multiple errors
+type multiError []error
+
+func (me multiError) Error() string {
+	return fmt.Sprintf("%d errors", len(me))
+}
+
+func (me multiError) Unwrap() []error {
+	return me
+}
+
+//tracedError has a stack trace like the errors of github.com/pkg/errors
+type tracedError struct{}
+
+type frames []string
+
+func (f frames) Format(s fmt.State, verb rune) {
+	for _, frame := range f {
+		fmt.Fprint(s, "\n"+frame)
+	}
+}
+
+func (te tracedError) Error() string {
+	return "traced"
+}
+
+func (te tracedError) StackTrace() frames {
+	return frames{"main.main\n\tmain.go:1"}
+}
+
+//valueError has the Error method on the value, which panics on a nil pointer
+type valueError struct {
+	message string
+}
+
+func (ve valueError) Error() string {
+	return ve.message
+}
+
+//panicError panics in all its methods
+type panicError struct{}
+
+func (pe panicError) Error() string {
+	panic("boom")
+}
+
+func (pe panicError) StackTrace() string {
+	panic("boom")
+}
@@ -0,0 +1,338 @@
+package logging
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	//LevelEnv is the environment variable of the log level, like "debug"
+	LevelEnv = "LOG_LEVEL"
+	//LevelsEnv is the environment variable of the levels of the named loggers,
+	//like "kafka=debug,db=warn"
+	LevelsEnv = "LOG_LEVELS"
+	//NameField is the field of the name of a named logger
+	NameField = "logger"
+)
+
+//Config sets the log levels, the redaction, the sampling and the outputs at init.
+//The environment variables LOG_LEVEL and LOG_LEVELS override the levels.
+type Config struct {
+	//Level is the level of the root logger. The level is not changed if it is empty.
+	Level string
+	//Levels are the levels of the named loggers by name. The named loggers without
+	//a level use the level of the root logger.
+	Levels map[string]string
+	//Redactor redacts the sensitive data of the log entries, like DefaultRedactor().
+	//Nothing is redacted if it is nil.
+	Redactor *Redactor
+	//Sampling drops the entries that exceed the sampling options if it is set.
+	//See SamplingFormatter. It is closed by the next InitLoggerWithConfig. The hooks
+	//of the logger, except the outputs, still get all the entries.
+	Sampling *SamplingOptions
+	//ReportCaller adds the file and the function of the caller of the WARN and
+	//above entries
+	ReportCaller bool
+	//Outputs are the destinations of the log entries with their minimum levels,
+	//instead of the output of the logger. See OutputHook.
+	Outputs []Output
+}
+
+//LevelState is the level of a logger. ExpiresAt is when a temporary level reverts.
+type LevelState struct {
+	Level     string     `json:"level"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+//LevelStatus is the level of the root logger and the levels set for the named loggers
+type LevelStatus struct {
+	LevelState
+	Loggers map[string]LevelState `json:"loggers,omitempty"`
+}
+
+//levelOverride is a level set for a logger. A temporary level reverts to the
+//previous one at expiresAt.
+type levelOverride struct {
+	level     logrus.Level
+	expiresAt time.Time
+	timer     *time.Timer
+	previous  *levelOverride
+}
+
+var registry = struct {
+	sync.Mutex
+	root      *logrus.Logger
+	named     map[string]*logrus.Logger
+	overrides map[string]*levelOverride
+}{
+	named:     map[string]*logrus.Logger{},
+	overrides: map[string]*levelOverride{},
+}
+
+//InitLoggerWithConfig is InitLogger with the log levels of the config. It fails
+//if any level is invalid. The logger, the redactor, the sampling and the levels
+//are global: the named loggers and the levels set before with SetLevel, Config.Levels
+//or LOG_LEVELS are reset to those of this call. cfg is not modified.
+func InitLoggerWithConfig(version string, l *logrus.Logger, cfg Config) error {
+	if l == nil {
+		l = logrus.StandardLogger()
+	}
+	var formatter logrus.Formatter = &CustomJSONFormatter{version: version, Redactor: cfg.Redactor, ReportCaller: cfg.ReportCaller}
+	var sampling *SamplingFormatter
+	if cfg.Sampling != nil {
+		sampling = NewSamplingFormatter(formatter, *cfg.Sampling)
+		formatter = sampling
+	}
+	if len(cfg.Outputs) > 0 {
+		if err := setOutputs(l, formatter, cfg.Outputs); err != nil {
+			return err
+		}
+	} else {
+		l.SetFormatter(formatter)
+	}
+	setRedactor(cfg.Redactor)
+	setSampling(sampling)
+	setRoot(l)
+
+	if level := os.Getenv(LevelEnv); level != "" {
+		cfg.Level = level
+	}
+	envLevels, err := parseLevels(os.Getenv(LevelsEnv))
+	if err != nil {
+		return err
+	}
+	//The levels of the caller are not modified
+	levels := make(map[string]string, len(cfg.Levels)+len(envLevels))
+	for name, level := range cfg.Levels {
+		levels[name] = level
+	}
+	for name, level := range envLevels {
+		levels[name] = level
+	}
+
+	resetLevels()
+	if cfg.Level != "" {
+		if err := SetLevel("", cfg.Level); err != nil {
+			return err
+		}
+	}
+	for name, level := range levels {
+		if err := SetLevel(name, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Named returns the logger of a component, like Named("kafka"). It has the
+//"logger" field and its own level, which is the level of the root logger unless
+//one is set with Config.Levels, LOG_LEVELS, or SetLevel. It writes to the output
+//of the root logger with the same formatter and hooks.
+func Named(name string) *logrus.Entry {
+	registry.Lock()
+	defer registry.Unlock()
+	l := registry.named[name]
+	if l == nil {
+		root := rootLogger()
+		l = &logrus.Logger{
+			Out:          root.Out,
+			Formatter:    root.Formatter,
+			Hooks:        root.Hooks,
+			ReportCaller: root.ReportCaller,
+			ExitFunc:     root.ExitFunc,
+			Level:        root.GetLevel(),
+		}
+		if o := registry.overrides[name]; o != nil {
+			l.SetLevel(o.level)
+		}
+		registry.named[name] = l
+	}
+	return l.WithField(NameField, name)
+}
+
+//Level returns the level of the logger. The name is "" for the root logger.
+func Level(name string) string {
+	registry.Lock()
+	defer registry.Unlock()
+	return levelOf(name).String()
+}
+
+//SetLevel sets the level of the logger until it is changed again. The name is
+//"" for the root logger.
+func SetLevel(name, level string) error {
+	return SetLevelFor(name, level, 0)
+}
+
+//SetLevelFor sets the level of the logger for the duration, after which it
+//reverts to the previous level. The level is permanent if the duration is 0.
+//The name is "" for the root logger.
+func SetLevelFor(name, level string, d time.Duration) error {
+	lvl, err := logrus.ParseLevel(strings.TrimSpace(level))
+	if err != nil {
+		return errors.New("Error setting the log level: " + err.Error())
+	}
+	registry.Lock()
+	defer registry.Unlock()
+
+	current := registry.overrides[name]
+	if current != nil && current.timer != nil {
+		current.timer.Stop()
+	}
+	o := &levelOverride{level: lvl}
+	if d > 0 {
+		//Revert to the level before any temporary level
+		o.previous = current
+		if current != nil && current.timer != nil {
+			o.previous = current.previous
+		}
+		if name == "" && o.previous == nil {
+			o.previous = &levelOverride{level: rootLogger().GetLevel()}
+		}
+		o.expiresAt = time.Now().Add(d)
+		o.timer = time.AfterFunc(d, func() {
+			registry.Lock()
+			defer registry.Unlock()
+			if registry.overrides[name] == o {
+				applyOverride(name, o.previous)
+			}
+		})
+	}
+	applyOverride(name, o)
+	return nil
+}
+
+//ResetLevel removes the level set for the named logger, so that it uses the
+//level of the root logger again
+func ResetLevel(name string) {
+	if name == "" {
+		return
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if o := registry.overrides[name]; o != nil && o.timer != nil {
+		o.timer.Stop()
+	}
+	applyOverride(name, nil)
+}
+
+//Levels returns the level of the root logger and the levels set for the named loggers
+func Levels() LevelStatus {
+	registry.Lock()
+	defer registry.Unlock()
+	status := LevelStatus{LevelState: LevelState{Level: rootLogger().GetLevel().String()}}
+	for name, o := range registry.overrides {
+		state := LevelState{Level: o.level.String()}
+		if !o.expiresAt.IsZero() {
+			expiresAt := o.expiresAt
+			state.ExpiresAt = &expiresAt
+		}
+		if name == "" {
+			status.ExpiresAt = state.ExpiresAt
+			continue
+		}
+		if status.Loggers == nil {
+			status.Loggers = map[string]LevelState{}
+		}
+		status.Loggers[name] = state
+	}
+	return status
+}
+
+//applyOverride sets the level of the logger, or removes it if o is nil. The
+//registry must be locked.
+func applyOverride(name string, o *levelOverride) {
+	if name == "" {
+		//The root logger always has a level, so only a temporary level is kept
+		if o != nil {
+			rootLogger().SetLevel(o.level)
+		}
+		if o == nil || o.timer == nil {
+			delete(registry.overrides, "")
+		} else {
+			registry.overrides[""] = o
+		}
+		for n, l := range registry.named {
+			if registry.overrides[n] == nil {
+				l.SetLevel(rootLogger().GetLevel())
+			}
+		}
+		return
+	}
+
+	if o == nil {
+		delete(registry.overrides, name)
+	} else {
+		registry.overrides[name] = o
+	}
+	if l := registry.named[name]; l != nil {
+		l.SetLevel(levelOf(name))
+	}
+}
+
+//resetLevels removes the levels set for the loggers, so that the named loggers
+//use the level of the root logger
+func resetLevels() {
+	registry.Lock()
+	defer registry.Unlock()
+	for _, o := range registry.overrides {
+		if o.timer != nil {
+			o.timer.Stop()
+		}
+	}
+	registry.overrides = map[string]*levelOverride{}
+	for _, l := range registry.named {
+		l.SetLevel(rootLogger().GetLevel())
+	}
+}
+
+//levelOf returns the level of the logger. The registry must be locked.
+func levelOf(name string) logrus.Level {
+	if o := registry.overrides[name]; o != nil && name != "" {
+		return o.level
+	}
+	return rootLogger().GetLevel()
+}
+
+//setRoot makes l the root logger and updates the named loggers to write with it
+func setRoot(l *logrus.Logger) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.root = l
+	for name, named := range registry.named {
+		named.SetOutput(l.Out)
+		named.SetFormatter(l.Formatter)
+		named.ReplaceHooks(l.Hooks)
+		named.SetReportCaller(l.ReportCaller)
+		named.SetLevel(levelOf(name))
+	}
+}
+
+//rootLogger returns the logger of the last InitLogger, or the standard logger.
+//The registry must be locked.
+func rootLogger() *logrus.Logger {
+	if registry.root == nil {
+		return logrus.StandardLogger()
+	}
+	return registry.root
+}
+
+//parseLevels parses levels like "kafka=debug,db=warn"
+func parseLevels(s string) (map[string]string, error) {
+	levels := map[string]string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errors.New("Error parsing the log levels `" + s + "`: expected name=level")
+		}
+		levels[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return levels, nil
+}
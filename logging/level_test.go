@@ -0,0 +1,111 @@
+package logging_test
+
+import (
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	. "github.com/coupa/foundation-go/logging"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Log levels", func() {
+	var (
+		logger *logrus.Logger
+		hook   *test.Hook
+	)
+
+	BeforeEach(func() {
+		logger, hook = test.NewNullLogger()
+		InitLogger("v1", logger)
+	})
+
+	AfterEach(func() {
+		os.Unsetenv(LevelEnv)
+		os.Unsetenv(LevelsEnv)
+		ResetLevel("kafka")
+		ResetLevel("db")
+		InitStandardLogger("")
+	})
+
+	Describe("InitLoggerWithConfig", func() {
+		It("sets the levels of the config and the environment variables", func() {
+			os.Setenv(LevelsEnv, "kafka=debug")
+			Expect(InitLoggerWithConfig("v1", logger, Config{Level: "warn", Levels: map[string]string{"db": "error"}})).To(Succeed())
+			Expect(logger.GetLevel()).To(Equal(logrus.WarnLevel))
+			Expect(Level("kafka")).To(Equal("debug"))
+			Expect(Level("db")).To(Equal("error"))
+
+			os.Setenv(LevelEnv, "error")
+			Expect(InitLoggerWithConfig("v1", logger, Config{Level: "debug"})).To(Succeed())
+			Expect(logger.GetLevel()).To(Equal(logrus.ErrorLevel))
+		})
+
+		It("does not modify the config and resets the levels of the previous init", func() {
+			os.Setenv(LevelsEnv, "kafka=debug")
+			levels := map[string]string{"db": "error"}
+			Expect(InitLoggerWithConfig("v1", logger, Config{Level: "info", Levels: levels})).To(Succeed())
+			Expect(levels).To(Equal(map[string]string{"db": "error"}))
+			Expect(Level("db")).To(Equal("error"))
+
+			os.Unsetenv(LevelsEnv)
+			Expect(InitLoggerWithConfig("v1", logger, Config{Level: "warn"})).To(Succeed())
+			Expect(Level("db")).To(Equal("warning"))
+			Expect(Level("kafka")).To(Equal("warning"))
+			Expect(Levels().Loggers).To(BeEmpty())
+		})
+
+		It("fails on an invalid level", func() {
+			Expect(InitLoggerWithConfig("v1", logger, Config{Level: "loud"})).NotTo(Succeed())
+			os.Setenv(LevelsEnv, "kafka")
+			Expect(InitLoggerWithConfig("v1", logger, Config{})).NotTo(Succeed())
+		})
+	})
+
+	Describe("Named", func() {
+		It("logs with the name and its own level", func() {
+			Expect(SetLevel("", "info")).To(Succeed())
+			kafka := Named("kafka")
+			kafka.Debug("hidden")
+			Expect(hook.Entries).To(BeEmpty())
+
+			Expect(SetLevel("kafka", "debug")).To(Succeed())
+			kafka.Debug("shown")
+			Expect(hook.LastEntry().Data[NameField]).To(Equal("kafka"))
+			Expect(hook.LastEntry().Data["version"]).To(Equal("v1"))
+
+			//The other loggers keep the root level
+			logger.Debug("hidden")
+			Named("db").Debug("hidden")
+			Expect(hook.Entries).To(HaveLen(1))
+
+			ResetLevel("kafka")
+			kafka.Debug("hidden")
+			Expect(hook.Entries).To(HaveLen(1))
+		})
+	})
+
+	Describe("SetLevelFor", func() {
+		It("reverts to the previous level after the duration", func() {
+			Expect(SetLevel("", "info")).To(Succeed())
+			Expect(SetLevelFor("", "debug", 50*time.Millisecond)).To(Succeed())
+			Expect(SetLevelFor("", "trace", 50*time.Millisecond)).To(Succeed())
+			Expect(logger.GetLevel()).To(Equal(logrus.TraceLevel))
+			Expect(Levels().ExpiresAt).NotTo(BeNil())
+			Eventually(func() logrus.Level { return logger.GetLevel() }).Should(Equal(logrus.InfoLevel))
+			Expect(Levels().ExpiresAt).To(BeNil())
+
+			Expect(SetLevel("kafka", "warn")).To(Succeed())
+			Expect(SetLevelFor("kafka", "debug", 50*time.Millisecond)).To(Succeed())
+			Expect(Level("kafka")).To(Equal("debug"))
+			Eventually(func() string { return Level("kafka") }).Should(Equal("warning"))
+		})
+
+		It("rejects an invalid level", func() {
+			Expect(SetLevelFor("", "loud", time.Minute)).NotTo(Succeed())
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 )
@@ -15,15 +16,25 @@ func InitStandardLogger(version string) {
 	InitLogger(version, nil)
 }
 
+//InitLogger sets the standard format and the log levels of the environment
+//variables LOG_LEVEL and LOG_LEVELS to l, or to the standard logger if l is nil.
+//l becomes the root logger of the named loggers and the runtime log levels.
 func InitLogger(version string, l *logrus.Logger) {
 	if l == nil {
 		l = logrus.StandardLogger()
 	}
-	l.SetFormatter(&CustomJSONFormatter{version: version})
+	if err := InitLoggerWithConfig(version, l, Config{}); err != nil {
+		l.WithError(err).Warn("Invalid log level")
+	}
 }
 
 type CustomJSONFormatter struct {
 	version string
+	//Redactor redacts the sensitive data of the entries. Nothing is redacted if it is nil.
+	Redactor *Redactor
+	//ReportCaller adds the file and the function of the caller of the WARN and
+	//above entries. The caller of all entries is added if the logger reports it.
+	ReportCaller bool
 }
 
 func (f *CustomJSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
@@ -37,7 +48,26 @@ func (f *CustomJSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 		entry.Data["message"] = entry.Message
 	}
 
-	serialized, err := json.Marshal(entry.Data)
+	//An error is rendered as its message with the wrapped errors and the stack trace
+	var errs []string
+	for k, v := range entry.Data {
+		if _, yes := v.(error); yes {
+			errs = append(errs, k)
+		}
+	}
+	for _, k := range errs {
+		for ek, ev := range ErrorFields(k, entry.Data[k].(error)) {
+			entry.Data[ek] = ev
+		}
+	}
+	if entry.HasCaller() || f.ReportCaller && entry.Level <= logrus.WarnLevel {
+		if caller := callerOf(entry); caller != nil {
+			entry.Data[FileField] = caller.File + ":" + strconv.Itoa(caller.Line)
+			entry.Data[FuncField] = caller.Function
+		}
+	}
+
+	serialized, err := json.Marshal(f.Redactor.RedactFields(entry.Data))
 	if err != nil {
 		return nil, fmt.Errorf("Failed to marshal fields to JSON: %v", err)
 	}
@@ -46,8 +76,14 @@ func (f *CustomJSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 
 //RL (request logging) records correlation id. You must use middleware.Correlation()
 //as a middleware in order for the correlation ID to appear.
+//
+//Deprecated: Use FromContext(h.Context()), which also works where only the context
+//is available and has the fields added with AddFields.
 func RL(h *http.Request, w http.ResponseWriter) *logrus.Entry {
+	if cl := loggerOf(h.Context()); cl != nil {
+		return FromContext(h.Context())
+	}
 	return logrus.WithFields(logrus.Fields{
-		"correlation_id": w.Header().Get("X-CORRELATION-ID"),
+		correlationIDField: w.Header().Get("X-CORRELATION-ID"),
 	})
 }
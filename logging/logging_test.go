@@ -1,8 +1,12 @@
 package logging_test
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 
 	. "github.com/coupa/foundation-go/logging"
@@ -41,4 +45,66 @@ var _ = Describe("Logging", func() {
 			})
 		})
 	})
+
+	Describe("FromContext", func() {
+		It("returns the request logger with the added fields", func() {
+			logger, hook := test.NewNullLogger()
+			ctx := NewContext(context.Background(), logger.WithField("correlation_id", "abc"))
+			AddFields(ctx, logrus.Fields{"tenant": "t1"})
+			AddField(ctx, "order_id", 5)
+
+			//A derived context shares the logger
+			child, cancel := context.WithCancel(ctx)
+			defer cancel()
+			FromContext(child).Info("hello")
+
+			data := hook.LastEntry().Data
+			Expect(data["correlation_id"]).To(Equal("abc"))
+			Expect(data["tenant"]).To(Equal("t1"))
+			Expect(data["order_id"]).To(Equal(5))
+			Expect(CorrelationID(child)).To(Equal("abc"))
+		})
+
+		It("returns the standard logger without a request logger", func() {
+			entry := FromContext(context.Background())
+			Expect(entry.Logger).To(Equal(logrus.StandardLogger()))
+			Expect(entry.Data).To(BeEmpty())
+
+			AddField(context.Background(), "ignored", true)
+			Expect(CorrelationID(context.Background())).To(BeEmpty())
+		})
+
+		It("has the IDs of the request", func() {
+			ctx := NewRequestContext(context.Background(), "abc", "user1")
+			Expect(CorrelationID(ctx)).To(Equal("abc"))
+			Expect(UserID(ctx)).To(Equal("user1"))
+
+			ctx = NewRequestContext(context.Background(), "", "")
+			Expect(FromContext(ctx).Data).To(BeEmpty())
+		})
+
+		It("does not use a string key of the context", func() {
+			ctx := NewRequestContext(context.Background(), "abc", "")
+			Expect(ctx.Value(ContextKey)).To(BeNil())
+			Expect(LoggerValue(ctx)).NotTo(BeNil())
+			Expect(LoggerValue(context.Background())).To(BeNil())
+
+			//Another package using the same string does not override the logger
+			ctx = context.WithValue(ctx, ContextKey, "something else")
+			Expect(CorrelationID(ctx)).To(Equal("abc"))
+		})
+	})
+
+	Describe("RL", func() {
+		It("uses the request logger of the request context", func() {
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req = req.WithContext(NewRequestContext(req.Context(), "from-context", ""))
+			resp := httptest.NewRecorder()
+			resp.Header().Set("X-CORRELATION-ID", "from-header")
+			Expect(RL(req, resp).Data["correlation_id"]).To(Equal("from-context"))
+
+			req, _ = http.NewRequest("GET", "/test", nil)
+			Expect(RL(req, resp).Data["correlation_id"]).To(Equal("from-header"))
+		})
+	})
 })
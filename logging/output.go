@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+//Output is a destination of the log entries, like os.Stdout, a RotatingFile or
+//a SyslogWriter
+type Output struct {
+	Writer io.Writer
+	//Level is the minimum level of the entries written to the output, like "warn".
+	//All the entries that the logger logs are written if it is empty.
+	Level string
+}
+
+//LevelWriter is a writer that handles the level of the entries, like SyslogWriter
+type LevelWriter interface {
+	WriteLevel(level logrus.Level, p []byte) (int, error)
+}
+
+//OutputHook writes every entry to the outputs whose minimum level it meets. The
+//entry is formatted once with Formatter. The output of the logger should be
+//discarded; InitLoggerWithConfig does so when Config.Outputs is set.
+type OutputHook struct {
+	Formatter logrus.Formatter
+
+	mu      sync.Mutex
+	outputs []levelOutput
+	levels  []logrus.Level
+}
+
+//levelOutput is an output with its parsed level
+type levelOutput struct {
+	io.Writer
+	level logrus.Level
+}
+
+//NewOutputHook creates a hook that fans out the entries to the outputs. It fails
+//if any output has no writer or an invalid level.
+func NewOutputHook(f logrus.Formatter, outputs ...Output) (*OutputHook, error) {
+	if len(outputs) == 0 {
+		return nil, errors.New("Error creating the log outputs: there is no output")
+	}
+	hook := &OutputHook{Formatter: f}
+	minimum := logrus.PanicLevel
+	for _, o := range outputs {
+		if o.Writer == nil {
+			return nil, errors.New("Error creating the log outputs: an output has no writer")
+		}
+		lvl := logrus.TraceLevel
+		if o.Level != "" {
+			var err error
+			if lvl, err = logrus.ParseLevel(strings.TrimSpace(o.Level)); err != nil {
+				return nil, errors.New("Error creating the log outputs: " + err.Error())
+			}
+		}
+		if lvl > minimum {
+			minimum = lvl
+		}
+		hook.outputs = append(hook.outputs, levelOutput{Writer: o.Writer, level: lvl})
+	}
+	for _, lvl := range logrus.AllLevels {
+		if lvl <= minimum {
+			hook.levels = append(hook.levels, lvl)
+		}
+	}
+	return hook, nil
+}
+
+func (h *OutputHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+//Fire formats a copy of the entry, so that the other hooks see the entry as it is
+func (h *OutputHook) Fire(entry *logrus.Entry) error {
+	data := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	e := &logrus.Entry{
+		Logger:  entry.Logger,
+		Data:    data,
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Caller:  entry.Caller,
+		Message: entry.Message,
+		Context: entry.Context,
+	}
+	serialized, err := h.Formatter.Format(e)
+	if err != nil || len(serialized) == 0 {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var errs []string
+	for _, o := range h.outputs {
+		if entry.Level > o.level {
+			continue
+		}
+		if lw, yes := o.Writer.(LevelWriter); yes {
+			_, err = lw.WriteLevel(entry.Level, serialized)
+		} else {
+			_, err = o.Writer.Write(serialized)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("Error writing the log entry: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+//setOutputs makes the logger write to the outputs with the hook. The formatter
+//of the logger is replaced since the hook formats the entries.
+func setOutputs(l *logrus.Logger, formatter logrus.Formatter, outputs []Output) error {
+	hook, err := NewOutputHook(formatter, outputs...)
+	if err != nil {
+		return err
+	}
+	hooks := logrus.LevelHooks{}
+	for lvl, lvlHooks := range l.Hooks {
+		for _, hk := range lvlHooks {
+			if _, yes := hk.(*OutputHook); !yes {
+				hooks[lvl] = append(hooks[lvl], hk)
+			}
+		}
+	}
+	hooks.Add(hook)
+	l.ReplaceHooks(hooks)
+	l.SetOutput(ioutil.Discard)
+	l.SetFormatter(discardFormatter{})
+	return nil
+}
+
+//discardFormatter formats nothing for the logger whose entries are written by an OutputHook
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
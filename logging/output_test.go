@@ -0,0 +1,108 @@
+package logging_test
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	. "github.com/coupa/foundation-go/logging"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Outputs", func() {
+	AfterEach(func() {
+		InitStandardLogger("")
+	})
+
+	It("fans out the entries to the outputs with their minimum levels", func() {
+		var all, warnings syncBuffer
+		logger, hook := test.NewNullLogger()
+		Expect(InitLoggerWithConfig("v1", logger, Config{Level: "debug", Redactor: DefaultRedactor(), Outputs: []Output{
+			{Writer: &all},
+			{Writer: &warnings, Level: "warn"},
+		}})).To(Succeed())
+
+		logger.Debug("debug")
+		logger.WithField("password", "p1").Warn("warn")
+		Named("kafka").Error("error")
+
+		Expect(all.String()).To(ContainSubstring(`"message":"debug"`))
+		Expect(all.String()).To(ContainSubstring(`"message":"warn"`))
+		Expect(all.String()).To(ContainSubstring(`"logger":"kafka"`))
+		Expect(warnings.String()).NotTo(ContainSubstring(`"message":"debug"`))
+		Expect(warnings.String()).To(ContainSubstring(`"password":"[REDACTED]"`))
+		Expect(bytes.Count([]byte(warnings.String()), []byte("\n"))).To(Equal(2))
+
+		//The other hooks see the entries as they are
+		Expect(hook.Entries).To(HaveLen(3))
+		Expect(hook.Entries[1].Data).To(Equal(logrus.Fields{"password": "p1"}))
+
+		//Initializing again replaces the outputs
+		var other syncBuffer
+		Expect(InitLoggerWithConfig("v1", logger, Config{Outputs: []Output{{Writer: &other}}})).To(Succeed())
+		logger.Info("info")
+		Expect(all.String()).NotTo(ContainSubstring(`"message":"info"`))
+		Expect(other.String()).To(ContainSubstring(`"message":"info"`))
+	})
+
+	It("writes concurrently", func() {
+		var out syncBuffer
+		logger := logrus.New()
+		Expect(InitLoggerWithConfig("v1", logger, Config{Outputs: []Output{{Writer: &out}}})).To(Succeed())
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					logger.Info("concurrent")
+				}
+			}()
+		}
+		wg.Wait()
+		Expect(bytes.Count([]byte(out.String()), []byte("\n"))).To(Equal(200))
+	})
+
+	It("fails on an invalid output", func() {
+		_, err := NewOutputHook(&logrus.JSONFormatter{})
+		Expect(err).To(HaveOccurred())
+		_, err = NewOutputHook(&logrus.JSONFormatter{}, Output{})
+		Expect(err).To(HaveOccurred())
+		_, err = NewOutputHook(&logrus.JSONFormatter{}, Output{Writer: &syncBuffer{}, Level: "loud"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reports the failed writes", func() {
+		hook, err := NewOutputHook(&logrus.JSONFormatter{}, Output{Writer: failingWriter{}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hook.Fire(logrus.NewEntry(logrus.New()))).To(MatchError(ContainSubstring("disk full")))
+	})
+})
+
+//syncBuffer is a buffer that can be read while it is written
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.buf.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.buf.String()
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
@@ -0,0 +1,256 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	//RedactMask replaces the sensitive values with the mask
+	RedactMask = "mask"
+	//RedactHash replaces the sensitive values with correlation tokens, so that the
+	//same values can still be correlated. See Redactor.HashKey.
+	RedactHash = "hash"
+	//RedactDrop removes the fields with sensitive values
+	RedactDrop = "drop"
+
+	//DefaultMask is the mask of the redacted values
+	DefaultMask = "[REDACTED]"
+)
+
+var (
+	//DefaultRedactKeys match the field names whose values are sensitive
+	DefaultRedactKeys = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)passw(or)?d|secret|token|authorization|api[_-]?key|cookie`),
+	}
+	//CardNumberValue matches the credit card numbers. Its matches are redacted only
+	//if they pass the Luhn check, so that the timestamps and the IDs in the strings
+	//are not redacted.
+	CardNumberValue = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	//DefaultRedactValues match the sensitive values, which are emails, credit card
+	//numbers and JWTs
+	DefaultRedactValues = []*regexp.Regexp{
+		regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		CardNumberValue,
+		regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	}
+)
+
+//Redactor redacts the sensitive data of log fields. The values of the fields
+//whose names match Keys are redacted as a whole, and the parts of the strings
+//that match Values are redacted. Nested maps, slices and structs are traversed.
+//The other values, like numbers and times, are not redacted.
+type Redactor struct {
+	Keys   []*regexp.Regexp
+	Values []*regexp.Regexp
+	//Action is RedactMask, RedactHash or RedactDrop. It defaults to RedactMask.
+	//A string that matches Values is dropped as a whole with RedactDrop.
+	Action string
+	//Mask defaults to DefaultMask
+	Mask string
+	//HashKey is the secret key of the HMAC-SHA256 of the values with RedactHash.
+	//Without it, the values are replaced with their truncated SHA-256, which is a
+	//correlation token and not a protection: the short or guessable values, like
+	//emails and card numbers, are found back by hashing the candidates.
+	HashKey []byte
+}
+
+//DefaultRedactor redacts passwords, tokens, authorization, API keys, emails,
+//credit card numbers and JWTs with the mask. Set it as Config.Redactor to use it.
+func DefaultRedactor() *Redactor {
+	return &Redactor{Keys: DefaultRedactKeys, Values: DefaultRedactValues}
+}
+
+var currentRedactor = struct {
+	sync.RWMutex
+	r *Redactor
+}{}
+
+//RedactFields redacts the fields with Config.Redactor of the last InitLogger. It
+//redacts nothing if Config.Redactor is not set. Use it for the data that is logged
+//with other formatters, such as in the request logger middleware.
+func RedactFields(fields logrus.Fields) logrus.Fields {
+	currentRedactor.RLock()
+	r := currentRedactor.r
+	currentRedactor.RUnlock()
+	return r.RedactFields(fields)
+}
+
+func setRedactor(r *Redactor) {
+	currentRedactor.Lock()
+	defer currentRedactor.Unlock()
+	currentRedactor.r = r
+}
+
+//RedactFields returns a copy of the fields with the sensitive data redacted. It
+//returns the fields as they are if r is nil.
+func (r *Redactor) RedactFields(fields logrus.Fields) logrus.Fields {
+	if r == nil {
+		return fields
+	}
+	redacted := make(logrus.Fields, len(fields))
+	for k, v := range fields {
+		if value, keep := r.Redact(k, v); keep {
+			redacted[k] = value
+		}
+	}
+	return redacted
+}
+
+//Redact returns the redacted value of a field. It returns false if the field
+//should be dropped.
+func (r *Redactor) Redact(key string, value interface{}) (interface{}, bool) {
+	if r == nil {
+		return value, true
+	}
+	if r.matchesKey(key) {
+		return r.redactWhole(value)
+	}
+	return r.redactValue(value)
+}
+
+func (r *Redactor) matchesKey(key string) bool {
+	for _, re := range r.Keys {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+//redactWhole redacts a value of a sensitive key
+func (r *Redactor) redactWhole(value interface{}) (interface{}, bool) {
+	switch r.Action {
+	case RedactDrop:
+		return nil, false
+	case RedactHash:
+		if s, yes := value.(string); yes {
+			return r.hash(s), true
+		}
+		data, _ := json.Marshal(value)
+		return r.hash(string(data)), true
+	default:
+		return r.mask(), true
+	}
+}
+
+//redactValue redacts the strings in the value and traverses the maps, slices
+//and structs
+func (r *Redactor) redactValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number, time.Time, *time.Time:
+		return v, true
+	case string:
+		return r.redactString(v)
+	case error:
+		return r.redactString(v.Error())
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			if item, keep := r.Redact(k, item); keep {
+				redacted[k] = item
+			}
+		}
+		return redacted, true
+	case []interface{}:
+		redacted := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if item, keep := r.redactValue(item); keep {
+				redacted = append(redacted, item)
+			}
+		}
+		return redacted, true
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		//Traverse the value as it would be marshaled to JSON
+		if generic, err := toGeneric(value); err == nil {
+			return r.redactValue(generic)
+		}
+	}
+	return value, true
+}
+
+func (r *Redactor) redactString(s string) (interface{}, bool) {
+	for _, re := range r.Values {
+		redacted := false
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			if re == CardNumberValue && !luhn(match) {
+				return match
+			}
+			redacted = true
+			if r.Action == RedactHash {
+				return r.hash(match)
+			}
+			return r.mask()
+		})
+		if redacted && r.Action == RedactDrop {
+			return nil, false
+		}
+	}
+	return s, true
+}
+
+//luhn returns whether the digits of the number pass the Luhn check of the credit
+//card numbers. The other characters are ignored.
+func luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			continue
+		}
+		d := int(number[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func (r *Redactor) mask() string {
+	if r.Mask == "" {
+		return DefaultMask
+	}
+	return r.Mask
+}
+
+//hash returns the correlation token of the value, which is its HMAC with HashKey
+//if it is set
+func (r *Redactor) hash(s string) string {
+	if len(r.HashKey) > 0 {
+		mac := hmac.New(sha256.New, r.HashKey)
+		mac.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:16])
+	}
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+//toGeneric converts the value to the maps, slices and values of its JSON
+func toGeneric(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to marshal the value: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	err = decoder.Decode(&generic)
+	return generic, err
+}
@@ -0,0 +1,348 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//backupTimeFormat is the time format in the names of the rotated files. It sorts
+//in the time order.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+//RotatingFileOptions control the rotation and the retention of a RotatingFile
+type RotatingFileOptions struct {
+	//Filename is the path of the log file. The rotated files are in the same
+	//directory, like "app-2020-01-02T15-04-05.000.log" for "app.log".
+	Filename string
+	//MaxSize rotates the file before it exceeds this many bytes. There is no size
+	//limit if it is 0.
+	MaxSize int64
+	//RotateEvery rotates the file when it has been open for this long, like
+	//24 * time.Hour. There is no time limit if it is 0.
+	RotateEvery time.Duration
+	//MaxBackups is the number of the rotated files to keep. All are kept if it is 0.
+	MaxBackups int
+	//MaxAge removes the rotated files older than it. None is removed if it is 0.
+	MaxAge time.Duration
+	//Compress gzips the rotated files
+	Compress bool
+}
+
+//RotatingFile is a log file that is rotated by size and time. The rotated files
+//are compressed and removed according to the options in the background after the
+//file is rotated by Write, so that the writes do not wait for them. It is safe
+//for concurrent use.
+type RotatingFile struct {
+	Options RotatingFileOptions
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	//cleaning serializes the compression and the removal of the rotated files,
+	//which are done in the order of the rotations
+	cleaning   sync.Mutex
+	queueMu    sync.Mutex
+	queue      []string
+	cleanupErr error
+}
+
+//NewRotatingFile opens the log file for appending, creating it and its directory
+//if needed
+func NewRotatingFile(opts RotatingFileOptions) (*RotatingFile, error) {
+	if opts.Filename == "" {
+		return nil, errors.New("Error opening the log file: there is no file name")
+	}
+	rf := &RotatingFile{Options: opts}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.file == nil {
+		return 0, errors.New("Error writing to the log file: it is closed")
+	}
+	tooLarge := rf.Options.MaxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.Options.MaxSize
+	tooOld := rf.Options.RotateEvery > 0 && time.Since(rf.openedAt) >= rf.Options.RotateEvery
+	if tooLarge || tooOld {
+		backup, err := rf.rotate()
+		if err != nil {
+			if rf.file == nil {
+				return 0, err
+			}
+			//The entry is still written to the file that failed to rotate
+			n, _ := rf.file.Write(p)
+			rf.size += int64(n)
+			return n, err
+		}
+		rf.enqueue(backup)
+		go func() {
+			if err := rf.cleanUp(); err != nil {
+				rf.queueMu.Lock()
+				if rf.cleanupErr == nil {
+					rf.cleanupErr = err
+				}
+				rf.queueMu.Unlock()
+			}
+		}()
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+//Rotate rotates the file now. It compresses and removes the rotated files before
+//it returns.
+func (rf *RotatingFile) Rotate() error {
+	rf.mu.Lock()
+	backup, err := rf.rotate()
+	rf.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	rf.enqueue(backup)
+	return rf.cleanUp()
+}
+
+//Close closes the file after the rotated files are compressed and removed. It
+//returns the first error of compressing or removing them in the background.
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	var err error
+	if rf.file != nil {
+		err = rf.file.Close()
+		rf.file = nil
+	}
+	rf.mu.Unlock()
+
+	cleanupErr := rf.cleanUp()
+	rf.queueMu.Lock()
+	defer rf.queueMu.Unlock()
+	if cleanupErr == nil {
+		cleanupErr, rf.cleanupErr = rf.cleanupErr, nil
+	}
+	if err == nil {
+		err = cleanupErr
+	}
+	return err
+}
+
+//Backups returns the paths of the rotated files, the newest first
+func (rf *RotatingFile) Backups() ([]string, error) {
+	dir := filepath.Dir(rf.Options.Filename)
+	prefix, ext := rf.backupPrefix()
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.New("Error listing the rotated log files: " + err.Error())
+	}
+	type backup struct {
+		path    string
+		rotated string
+		seq     int
+	}
+	var found []backup
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		//The name has the time of the rotation after the prefix, and a sequence
+		//number if several files were rotated in the same millisecond
+		rest := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"), ext)
+		if len(rest) < len(backupTimeFormat) || !strings.HasSuffix(strings.TrimSuffix(name, ".gz"), ext) {
+			continue
+		}
+		rotated := rest[:len(backupTimeFormat)]
+		if _, err := time.Parse(backupTimeFormat, rotated); err != nil {
+			continue
+		}
+		seq := 1
+		if rest != rotated {
+			if !strings.HasPrefix(rest[len(rotated):], "-") {
+				continue
+			}
+			if seq, err = strconv.Atoi(rest[len(rotated)+1:]); err != nil {
+				continue
+			}
+		}
+		found = append(found, backup{path: filepath.Join(dir, name), rotated: rotated, seq: seq})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].rotated != found[j].rotated {
+			return found[i].rotated > found[j].rotated
+		}
+		return found[i].seq > found[j].seq
+	})
+	backups := make([]string, len(found))
+	for i, b := range found {
+		backups[i] = b.path
+	}
+	return backups, nil
+}
+
+func (rf *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(rf.Options.Filename), 0755); err != nil {
+		return errors.New("Error creating the log directory: " + err.Error())
+	}
+	f, err := os.OpenFile(rf.Options.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.New("Error opening the log file: " + err.Error())
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.New("Error opening the log file: " + err.Error())
+	}
+	rf.file = f
+	rf.size = info.Size()
+	rf.openedAt = time.Now()
+	return nil
+}
+
+//rotate renames the file to a backup and opens a new one. It returns the name of
+//the backup, or "" if there was no file to rename. The file is reopened if it
+//fails to be renamed, so that the logging goes on. The lock must be held.
+func (rf *RotatingFile) rotate() (string, error) {
+	if rf.file != nil {
+		if err := rf.file.Close(); err != nil {
+			return "", errors.New("Error closing the log file: " + err.Error())
+		}
+		rf.file = nil
+	}
+	backup := rf.backupName(time.Now())
+	if err := os.Rename(rf.Options.Filename, backup); err != nil {
+		if !os.IsNotExist(err) {
+			if openErr := rf.open(); openErr != nil {
+				return "", openErr
+			}
+			return "", errors.New("Error rotating the log file: " + err.Error())
+		}
+		backup = ""
+	}
+	return backup, rf.open()
+}
+
+//enqueue adds the backup to be cleaned up after the backups rotated before it
+func (rf *RotatingFile) enqueue(backup string) {
+	rf.queueMu.Lock()
+	defer rf.queueMu.Unlock()
+	rf.queue = append(rf.queue, backup)
+}
+
+//cleanUp compresses the queued backups in the order of the rotations and then
+//removes the old backups from all of them. A queued backup that was already
+//removed as an old one is skipped. It does not need the lock of the file.
+func (rf *RotatingFile) cleanUp() error {
+	rf.cleaning.Lock()
+	defer rf.cleaning.Unlock()
+	rf.queueMu.Lock()
+	queue := rf.queue
+	rf.queue = nil
+	rf.queueMu.Unlock()
+
+	var first error
+	for _, backup := range queue {
+		if !rf.Options.Compress || backup == "" {
+			continue
+		}
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			continue
+		}
+		if err := compressFile(backup); err != nil && first == nil {
+			first = err
+		}
+	}
+	if err := rf.removeOldBackups(); err != nil && first == nil {
+		first = err
+	}
+	return first
+}
+
+//backupPrefix returns the prefix and the extension of the names of the backups
+func (rf *RotatingFile) backupPrefix() (string, string) {
+	base := filepath.Base(rf.Options.Filename)
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-", ext
+}
+
+//backupName returns a unique name of the backup rotated at t
+func (rf *RotatingFile) backupName(t time.Time) string {
+	prefix, ext := rf.backupPrefix()
+	name := filepath.Join(filepath.Dir(rf.Options.Filename), prefix+t.UTC().Format(backupTimeFormat))
+	for i := 1; ; i++ {
+		candidate := name + ext
+		if i > 1 {
+			candidate = name + "-" + strconv.Itoa(i) + ext
+		}
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			if _, err := os.Stat(candidate + ".gz"); os.IsNotExist(err) {
+				return candidate
+			}
+		}
+	}
+}
+
+func (rf *RotatingFile) removeOldBackups() error {
+	if rf.Options.MaxBackups <= 0 && rf.Options.MaxAge <= 0 {
+		return nil
+	}
+	backups, err := rf.Backups()
+	if err != nil {
+		return err
+	}
+	for i, backup := range backups {
+		remove := rf.Options.MaxBackups > 0 && i >= rf.Options.MaxBackups
+		if !remove && rf.Options.MaxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && time.Since(info.ModTime()) > rf.Options.MaxAge {
+				remove = true
+			}
+		}
+		if remove {
+			if err := os.Remove(backup); err != nil {
+				return errors.New("Error removing the rotated log file: " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+//compressFile gzips the file to "<name>.gz" and removes it
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return errors.New("Error compressing the rotated log file: " + err.Error())
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.New("Error compressing the rotated log file: " + err.Error())
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(name + ".gz")
+		return errors.New("Error compressing the rotated log file: " + err.Error())
+	}
+	src.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,158 @@
+package logging_test
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/coupa/foundation-go/logging"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RotatingFile", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "rotating")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	read := func(name string) string {
+		data, err := ioutil.ReadFile(name)
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	It("rotates by size and keeps MaxBackups files", func() {
+		name := filepath.Join(dir, "logs", "app.log")
+		rf, err := NewRotatingFile(RotatingFileOptions{Filename: name, MaxSize: 10, MaxBackups: 2})
+		Expect(err).NotTo(HaveOccurred())
+		defer rf.Close()
+
+		for _, line := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"} {
+			_, err := rf.Write([]byte(line))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		//Close waits for the old backups to be removed in the background
+		Expect(rf.Close()).To(Succeed())
+		Expect(read(name)).To(Equal("line 4\n"))
+		backups, err := rf.Backups()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backups).To(HaveLen(2))
+		Expect(read(backups[0])).To(Equal("line 3\n"))
+		Expect(read(backups[1])).To(Equal("line 2\n"))
+		Expect(filepath.Base(backups[0])).To(HavePrefix("app-"))
+		Expect(filepath.Base(backups[0])).To(HaveSuffix(".log"))
+	})
+
+	It("rotates by time and compresses the rotated files", func() {
+		name := filepath.Join(dir, "app.log")
+		ioutil.WriteFile(filepath.Join(dir, "app-other.log"), []byte("not a backup"), 0644)
+		rf, err := NewRotatingFile(RotatingFileOptions{Filename: name, RotateEvery: 20 * time.Millisecond, Compress: true})
+		Expect(err).NotTo(HaveOccurred())
+		defer rf.Close()
+
+		rf.Write([]byte("old\n"))
+		time.Sleep(30 * time.Millisecond)
+		rf.Write([]byte("new\n"))
+		Expect(rf.Close()).To(Succeed())
+		Expect(read(name)).To(Equal("new\n"))
+
+		backups, _ := rf.Backups()
+		Expect(backups).To(HaveLen(1))
+		Expect(backups[0]).To(HaveSuffix(".log.gz"))
+		f, _ := os.Open(backups[0])
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		Expect(err).NotTo(HaveOccurred())
+		data, _ := ioutil.ReadAll(gz)
+		Expect(string(data)).To(Equal("old\n"))
+	})
+
+	It("compresses and removes the backups of fast rotations in order", func() {
+		name := filepath.Join(dir, "app.log")
+		rf, err := NewRotatingFile(RotatingFileOptions{Filename: name, MaxSize: 8, MaxBackups: 2, Compress: true})
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 30; i++ {
+			_, err := rf.Write([]byte(strconv.Itoa(100+i) + "\n"))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(rf.Close()).To(Succeed())
+
+		backups, _ := rf.Backups()
+		Expect(backups).To(HaveLen(2))
+		var contents []string
+		for _, backup := range backups {
+			Expect(backup).To(HaveSuffix(".log.gz"))
+			f, err := os.Open(backup)
+			Expect(err).NotTo(HaveOccurred())
+			gz, err := gzip.NewReader(f)
+			Expect(err).NotTo(HaveOccurred())
+			data, _ := ioutil.ReadAll(gz)
+			f.Close()
+			contents = append(contents, string(data))
+		}
+		Expect(contents).To(Equal([]string{"126\n127\n", "124\n125\n"}))
+		Expect(read(name)).To(Equal("128\n129\n"))
+	})
+
+	It("removes the rotated files older than MaxAge", func() {
+		name := filepath.Join(dir, "app.log")
+		old := filepath.Join(dir, "app-2020-01-02T15-04-05.000.log")
+		ioutil.WriteFile(old, []byte("old"), 0644)
+		os.Chtimes(old, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour))
+
+		rf, err := NewRotatingFile(RotatingFileOptions{Filename: name, MaxAge: 24 * time.Hour})
+		Expect(err).NotTo(HaveOccurred())
+		defer rf.Close()
+		rf.Write([]byte("current\n"))
+		Expect(rf.Rotate()).To(Succeed())
+
+		backups, _ := rf.Backups()
+		Expect(backups).To(HaveLen(1))
+		Expect(read(backups[0])).To(Equal("current\n"))
+		_, err = os.Stat(old)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("writes whole lines concurrently", func() {
+		name := filepath.Join(dir, "app.log")
+		rf, err := NewRotatingFile(RotatingFileOptions{Filename: name, MaxSize: 100})
+		Expect(err).NotTo(HaveOccurred())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					rf.Write([]byte("0123456789\n"))
+				}
+			}()
+		}
+		wg.Wait()
+		Expect(rf.Close()).To(Succeed())
+		_, err = rf.Write([]byte("closed\n"))
+		Expect(err).To(HaveOccurred())
+
+		backups, _ := rf.Backups()
+		lines := strings.Count(read(name), "\n")
+		for _, backup := range backups {
+			content := read(backup)
+			Expect(len(content)).To(BeNumerically("<=", 100))
+			lines += strings.Count(content, "\n")
+		}
+		Expect(lines).To(Equal(100))
+	})
+})
@@ -0,0 +1,206 @@
+package logging
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//SuppressedMessage is the message of the summary of the suppressed entries
+const SuppressedMessage = "Suppressed log entries"
+
+//SamplingOptions control the SamplingFormatter. The counts are per interval.
+type SamplingOptions struct {
+	//Interval defaults to 1 second
+	Interval time.Duration
+	//First is the number of the entries with the same key that are logged
+	First int
+	//Thereafter logs every Thereafter-th entry after the first ones. The others
+	//are dropped if it is 0.
+	Thereafter int
+	//LevelCaps limit the number of the entries of the levels, like
+	//{logrus.DebugLevel: 100}. The levels without a cap are not limited.
+	LevelCaps map[logrus.Level]int
+	//Key returns the key of the entries that are sampled together. It defaults to
+	//the level and the message.
+	Key func(*logrus.Entry) string
+}
+
+//SamplingFormatter wraps a formatter to drop the entries that exceed the sampling
+//options, so that a hot path does not overwhelm the log pipeline. Every interval
+//in which some entries were suppressed, a WARN summary entry with the number of
+//the suppressed entries is logged on its own with the logger of the entries. Close
+//it when it is no longer used.
+//
+//The entries are sampled when they are formatted, after the hooks have fired, so
+//the hooks of the logger are NOT sampled: a hook that sends the entries elsewhere,
+//like an error tracker, still gets all of them. Only the OutputHook of
+//Config.Outputs, which formats with this formatter, is sampled.
+type SamplingFormatter struct {
+	Formatter logrus.Formatter
+	Options   SamplingOptions
+
+	suppressed int64
+	mu         sync.Mutex
+	current    atomic.Value //*sampleWindow
+	logger     atomic.Value //*logrus.Logger
+	started    sync.Once
+	stop       chan struct{}
+	closed     bool
+}
+
+//sampleWindow counts the entries of an interval
+type sampleWindow struct {
+	index  int64
+	keys   sync.Map //key -> *int64
+	levels [logrus.TraceLevel + 1]int64
+}
+
+//summaryKey marks the context of the summary entries, which are not sampled
+type summaryKey struct{}
+
+var currentSampling = struct {
+	sync.Mutex
+	sf *SamplingFormatter
+}{}
+
+//setSampling closes the sampling formatter of the previous InitLoggerWithConfig,
+//which logs its last summary
+func setSampling(sf *SamplingFormatter) {
+	currentSampling.Lock()
+	previous := currentSampling.sf
+	currentSampling.sf = sf
+	currentSampling.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
+}
+
+//NewSamplingFormatter wraps the formatter with the sampling options
+func NewSamplingFormatter(f logrus.Formatter, opts SamplingOptions) *SamplingFormatter {
+	return &SamplingFormatter{Formatter: f, Options: opts}
+}
+
+func (sf *SamplingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if entry.Context != nil && entry.Context.Value(summaryKey{}) != nil {
+		return sf.Formatter.Format(entry)
+	}
+	if entry.Logger != nil {
+		sf.logger.Store(entry.Logger)
+		sf.started.Do(sf.start)
+	}
+	if !sf.sample(sf.window(entry.Time), entry) {
+		atomic.AddInt64(&sf.suppressed, 1)
+		return nil, nil
+	}
+	return sf.Formatter.Format(entry)
+}
+
+//Close stops logging the summaries every interval and logs the summary of the
+//entries suppressed since the last one
+func (sf *SamplingFormatter) Close() error {
+	sf.mu.Lock()
+	if !sf.closed {
+		sf.closed = true
+		if sf.stop != nil {
+			close(sf.stop)
+		}
+	}
+	sf.mu.Unlock()
+	sf.flush()
+	return nil
+}
+
+//start starts logging the summaries every interval unless it is closed
+func (sf *SamplingFormatter) start() {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.closed {
+		return
+	}
+	sf.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(sf.interval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sf.flush()
+			case <-stop:
+				return
+			}
+		}
+	}(sf.stop)
+}
+
+//flush logs the summary of the entries suppressed since the last summary, if any
+func (sf *SamplingFormatter) flush() {
+	logger, _ := sf.logger.Load().(*logrus.Logger)
+	if logger == nil {
+		return
+	}
+	if n := atomic.SwapInt64(&sf.suppressed, 0); n > 0 {
+		ctx := context.WithValue(context.Background(), summaryKey{}, true)
+		logger.WithContext(ctx).WithFields(logrus.Fields{
+			"suppressed": n,
+			"interval":   sf.interval().Seconds(),
+		}).Warn(SuppressedMessage)
+	}
+}
+
+//window returns the window of the time
+func (sf *SamplingFormatter) window(t time.Time) *sampleWindow {
+	index := t.UnixNano() / int64(sf.interval())
+	if w, _ := sf.current.Load().(*sampleWindow); w != nil && w.index >= index {
+		return w
+	}
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	w, _ := sf.current.Load().(*sampleWindow)
+	if w != nil && w.index >= index {
+		return w
+	}
+	next := &sampleWindow{index: index}
+	sf.current.Store(next)
+	return next
+}
+
+//sample checks if the entry is logged and counts it
+func (sf *SamplingFormatter) sample(w *sampleWindow, entry *logrus.Entry) bool {
+	if limit, yes := sf.Options.LevelCaps[entry.Level]; yes && entry.Level <= logrus.TraceLevel {
+		if atomic.AddInt64(&w.levels[entry.Level], 1) > int64(limit) {
+			return false
+		}
+	}
+	if sf.Options.First <= 0 && sf.Options.Thereafter <= 0 {
+		return true
+	}
+
+	key := sf.key(entry)
+	counter, found := w.keys.Load(key)
+	if !found {
+		counter, _ = w.keys.LoadOrStore(key, new(int64))
+	}
+	n := atomic.AddInt64(counter.(*int64), 1)
+	if n <= int64(sf.Options.First) {
+		return true
+	}
+	return sf.Options.Thereafter > 0 && (n-int64(sf.Options.First))%int64(sf.Options.Thereafter) == 0
+}
+
+func (sf *SamplingFormatter) key(entry *logrus.Entry) string {
+	if sf.Options.Key != nil {
+		return sf.Options.Key(entry)
+	}
+	return entry.Level.String() + " " + entry.Message
+}
+
+func (sf *SamplingFormatter) interval() time.Duration {
+	if sf.Options.Interval <= 0 {
+		return time.Second
+	}
+	return sf.Options.Interval
+}
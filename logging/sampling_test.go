@@ -0,0 +1,155 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	. "github.com/coupa/foundation-go/logging"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SamplingFormatter", func() {
+	var (
+		logger *logrus.Logger
+		start  time.Time
+	)
+
+	BeforeEach(func() {
+		logger = logrus.New()
+		logger.SetOutput(ioutil.Discard)
+		start = time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	})
+
+	format := func(sf *SamplingFormatter, level logrus.Level, message string, t time.Time) []map[string]interface{} {
+		entry := &logrus.Entry{Logger: logger, Data: logrus.Fields{}, Time: t, Level: level, Message: message}
+		data, err := sf.Format(entry)
+		Expect(err).NotTo(HaveOccurred())
+		var lines []map[string]interface{}
+		for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+			if len(line) > 0 {
+				var m map[string]interface{}
+				Expect(json.Unmarshal(line, &m)).To(Succeed())
+				lines = append(lines, m)
+			}
+		}
+		return lines
+	}
+
+	It("logs the first entries of a key and then every Mth", func() {
+		sf := NewSamplingFormatter(&logrus.JSONFormatter{}, SamplingOptions{Interval: time.Second, First: 2, Thereafter: 3})
+		defer sf.Close()
+		var logged []int
+		for i := 1; i <= 10; i++ {
+			if len(format(sf, logrus.ErrorLevel, "hot", start)) > 0 {
+				logged = append(logged, i)
+			}
+		}
+		Expect(logged).To(Equal([]int{1, 2, 5, 8}))
+		//The other keys are counted separately
+		Expect(format(sf, logrus.ErrorLevel, "cold", start)).To(HaveLen(1))
+
+		//The summary of the suppressed entries is logged on its own with the logger
+		var out bytes.Buffer
+		logger.SetOutput(&out)
+		logger.SetFormatter(sf)
+		Expect(format(sf, logrus.ErrorLevel, "hot", start.Add(time.Second))).To(HaveLen(1))
+		Expect(format(sf, logrus.ErrorLevel, "hot", start.Add(2*time.Second))).To(HaveLen(1))
+		Expect(out.Len()).To(BeZero())
+		Expect(sf.Close()).To(Succeed())
+
+		var summary map[string]interface{}
+		Expect(json.Unmarshal(out.Bytes(), &summary)).To(Succeed())
+		Expect(summary["msg"]).To(Equal(SuppressedMessage))
+		Expect(summary["suppressed"]).To(Equal(6.0))
+		Expect(summary["level"]).To(Equal("warning"))
+
+		//Nothing is left to summarize
+		out.Reset()
+		Expect(sf.Close()).To(Succeed())
+		Expect(out.Len()).To(BeZero())
+	})
+
+	It("logs the summary every interval to the outputs of its level", func() {
+		var all, warnings syncBuffer
+		Expect(InitLoggerWithConfig("v1", logger, Config{
+			Sampling: &SamplingOptions{Interval: 20 * time.Millisecond, First: 1},
+			Outputs:  []Output{{Writer: &all}, {Writer: &warnings, Level: "warn"}},
+		})).To(Succeed())
+		defer InitStandardLogger("")
+		for i := 0; i < 3; i++ {
+			logger.Info("hot")
+		}
+
+		Eventually(warnings.String).Should(ContainSubstring(`"message":"` + SuppressedMessage + `"`))
+		Expect(warnings.String()).NotTo(ContainSubstring(`"message":"hot"`))
+		Expect(all.String()).To(ContainSubstring(`"message":"hot"`))
+		Eventually(all.String).Should(ContainSubstring(SuppressedMessage))
+	})
+
+	It("caps the number of entries per level", func() {
+		sf := NewSamplingFormatter(&logrus.JSONFormatter{}, SamplingOptions{LevelCaps: map[logrus.Level]int{logrus.DebugLevel: 2}})
+		defer sf.Close()
+		count := 0
+		for i := 0; i < 5; i++ {
+			count += len(format(sf, logrus.DebugLevel, "different", start.Add(time.Duration(i)*time.Millisecond)))
+			count += len(format(sf, logrus.InfoLevel, "not capped", start))
+		}
+		Expect(count).To(Equal(7))
+	})
+
+	It("is safe for concurrent use", func() {
+		sf := NewSamplingFormatter(&logrus.JSONFormatter{}, SamplingOptions{First: 10})
+		defer sf.Close()
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		logged := 0
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					entry := &logrus.Entry{Logger: logger, Data: logrus.Fields{}, Time: start, Level: logrus.ErrorLevel, Message: "hot"}
+					if data, _ := sf.Format(entry); len(data) > 0 {
+						mu.Lock()
+						logged++
+						mu.Unlock()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		Expect(logged).To(Equal(10))
+	})
+
+	It("is set up with the config", func() {
+		var out bytes.Buffer
+		logger.SetOutput(&out)
+		Expect(InitLoggerWithConfig("v1", logger, Config{Sampling: &SamplingOptions{Interval: time.Hour, First: 1}})).To(Succeed())
+		defer InitStandardLogger("")
+		for i := 0; i < 3; i++ {
+			logger.Error("hot")
+		}
+		Expect(bytes.Count(out.Bytes(), []byte("\n"))).To(Equal(1))
+		Expect(out.String()).To(ContainSubstring(`"version":"v1"`))
+	})
+
+	It("does not sample the hooks of the logger", func() {
+		var out bytes.Buffer
+		logger.SetOutput(&out)
+		hook := test.NewLocal(logger)
+		Expect(InitLoggerWithConfig("v1", logger, Config{Sampling: &SamplingOptions{Interval: time.Hour, First: 1}})).To(Succeed())
+		defer InitStandardLogger("")
+		for i := 0; i < 3; i++ {
+			logger.Error("hot")
+		}
+		Expect(bytes.Count(out.Bytes(), []byte("\n"))).To(Equal(1))
+		Expect(hook.AllEntries()).To(HaveLen(3))
+	})
+})
@@ -0,0 +1,180 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//FacilityLocal0 is the syslog facility local0. The facilities are 0 to 23.
+const FacilityLocal0 = 16
+
+//syslogSeverities are the syslog severities of the log levels
+var syslogSeverities = map[logrus.Level]int{
+	logrus.PanicLevel: 0, //emerg
+	logrus.FatalLevel: 2, //crit
+	logrus.ErrorLevel: 3, //err
+	logrus.WarnLevel:  4, //warning
+	logrus.InfoLevel:  6, //info
+	logrus.DebugLevel: 7, //debug
+	logrus.TraceLevel: 7,
+}
+
+//SyslogOptions control a SyslogWriter
+type SyslogOptions struct {
+	//Network is "unixgram" or "unix" for a local socket, or "udp" or "tcp". It
+	//defaults to "unixgram".
+	Network string
+	//Address is like "/dev/log" or "localhost:514". It defaults to "/dev/log".
+	Address string
+	//Facility defaults to 1, the user-level messages
+	Facility int
+	//AppName defaults to the name of the executable
+	AppName string
+	//Hostname defaults to the host name
+	Hostname string
+	//WriteTimeout limits the time of a write, so that a stalled syslog server does
+	//not block the logging. It defaults to 5 seconds.
+	WriteTimeout time.Duration
+}
+
+//SyslogWriter writes the log entries as RFC 5424 syslog messages with the
+//severities of their levels. The messages are framed with the octet counting of
+//RFC 6587 over TCP, and end with a newline over a unix stream socket. It
+//reconnects once when a write fails, and it is safe for concurrent use.
+type SyslogWriter struct {
+	Options SyslogOptions
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+//NewSyslogWriter connects to the syslog server
+func NewSyslogWriter(opts SyslogOptions) (*SyslogWriter, error) {
+	if opts.Network == "" {
+		opts.Network = "unixgram"
+	}
+	if opts.Address == "" {
+		opts.Address = "/dev/log"
+	}
+	if opts.Facility == 0 {
+		opts.Facility = 1
+	}
+	if opts.Facility < 0 || opts.Facility > 23 {
+		return nil, errors.New("Error creating the syslog writer: invalid facility " + strconv.Itoa(opts.Facility))
+	}
+	if opts.AppName == "" {
+		opts.AppName = "-"
+		if exe, err := os.Executable(); err == nil {
+			opts.AppName = filepath.Base(exe)
+		}
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 5 * time.Second
+	}
+	sw := &SyslogWriter{Options: opts}
+	if err := sw.connect(); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+//Write writes p with the info severity
+func (sw *SyslogWriter) Write(p []byte) (int, error) {
+	return sw.WriteLevel(logrus.InfoLevel, p)
+}
+
+//WriteLevel writes p with the severity of the level
+func (sw *SyslogWriter) WriteLevel(level logrus.Level, p []byte) (int, error) {
+	msg := sw.format(level, time.Now(), p)
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.conn == nil {
+		if err := sw.connect(); err != nil {
+			return 0, err
+		}
+	}
+	if err := sw.write(msg); err != nil {
+		//Reconnect once, like after the syslog server restarts
+		sw.conn.Close()
+		sw.conn = nil
+		if err := sw.connect(); err != nil {
+			return 0, err
+		}
+		if err := sw.write(msg); err != nil {
+			return 0, errors.New("Error writing to syslog: " + err.Error())
+		}
+	}
+	return len(p), nil
+}
+
+//write writes the message before the write timeout. The lock must be held.
+func (sw *SyslogWriter) write(msg []byte) error {
+	if err := sw.conn.SetWriteDeadline(time.Now().Add(sw.Options.WriteTimeout)); err != nil {
+		return err
+	}
+	_, err := sw.conn.Write(msg)
+	return err
+}
+
+func (sw *SyslogWriter) Close() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.conn == nil {
+		return nil
+	}
+	err := sw.conn.Close()
+	sw.conn = nil
+	return err
+}
+
+//format returns the RFC 5424 message:
+//<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (sw *SyslogWriter) format(level logrus.Level, t time.Time, p []byte) []byte {
+	severity, found := syslogSeverities[level]
+	if !found {
+		severity = syslogSeverities[logrus.InfoLevel]
+	}
+	var msg bytes.Buffer
+	msg.WriteString("<" + strconv.Itoa(sw.Options.Facility*8+severity) + ">1 ")
+	msg.WriteString(t.UTC().Format("2006-01-02T15:04:05.000000Z07:00") + " ")
+	msg.WriteString(syslogField(sw.Options.Hostname) + " " + syslogField(sw.Options.AppName) + " ")
+	msg.WriteString(strconv.Itoa(os.Getpid()) + " - - ")
+	msg.Write(bytes.TrimRight(p, "\n"))
+
+	switch sw.Options.Network {
+	case "tcp", "tcp4", "tcp6":
+		return append([]byte(strconv.Itoa(msg.Len())+" "), msg.Bytes()...)
+	case "unix":
+		return append(msg.Bytes(), '\n')
+	}
+	return msg.Bytes()
+}
+
+func (sw *SyslogWriter) connect() error {
+	conn, err := net.DialTimeout(sw.Options.Network, sw.Options.Address, 5*time.Second)
+	if err != nil {
+		return errors.New("Error connecting to syslog: " + err.Error())
+	}
+	sw.conn = conn
+	return nil
+}
+
+//syslogField returns "-" for an empty header field and removes the spaces
+func syslogField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(s, " ", "_")
+}
@@ -0,0 +1,105 @@
+package logging_test
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	. "github.com/coupa/foundation-go/logging"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SyslogWriter", func() {
+	It("writes RFC 5424 messages over UDP", func() {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		sw, err := NewSyslogWriter(SyslogOptions{Network: "udp", Address: conn.LocalAddr().String(), Facility: FacilityLocal0, AppName: "app", Hostname: "host 1"})
+		Expect(err).NotTo(HaveOccurred())
+		defer sw.Close()
+
+		_, err = sw.WriteLevel(logrus.ErrorLevel, []byte(`{"message":"failed"}`+"\n"))
+		Expect(err).NotTo(HaveOccurred())
+
+		buf := make([]byte, 1024)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		Expect(err).NotTo(HaveOccurred())
+		msg := string(buf[:n])
+		//local0 is 16 and err is 3, so the priority is 16*8+3
+		Expect(msg).To(HavePrefix("<131>1 "))
+		Expect(msg).To(HaveSuffix(" host_1 app " + strconv.Itoa(os.Getpid()) + ` - - {"message":"failed"}`))
+
+		fields := strings.Fields(msg)
+		_, err = time.Parse(time.RFC3339Nano, fields[1])
+		Expect(err).NotTo(HaveOccurred())
+
+		sw.Write([]byte("info"))
+		n, _, _ = conn.ReadFrom(buf)
+		Expect(string(buf[:n])).To(HavePrefix("<134>1 "))
+	})
+
+	It("frames the messages over TCP", func() {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer lis.Close()
+		received := make(chan string, 1)
+		go func() {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			length, _ := r.ReadString(' ')
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			msg := make([]byte, n)
+			r.Read(msg)
+			received <- string(msg)
+		}()
+
+		sw, err := NewSyslogWriter(SyslogOptions{Network: "tcp", Address: lis.Addr().String(), AppName: "app", Hostname: "host"})
+		Expect(err).NotTo(HaveOccurred())
+		defer sw.Close()
+		sw.WriteLevel(logrus.WarnLevel, []byte("warning\n"))
+
+		var msg string
+		Eventually(received, 5*time.Second).Should(Receive(&msg))
+		Expect(msg).To(HavePrefix("<12>1 "))
+		Expect(msg).To(HaveSuffix(" - - warning"))
+	})
+
+	It("times out writing to a stalled server", func() {
+		//The server accepts the connections but never reads them
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer lis.Close()
+
+		sw, err := NewSyslogWriter(SyslogOptions{Network: "tcp", Address: lis.Addr().String(), WriteTimeout: 50 * time.Millisecond})
+		Expect(err).NotTo(HaveOccurred())
+		defer sw.Close()
+
+		written := make(chan error, 1)
+		go func() {
+			_, err := sw.WriteLevel(logrus.InfoLevel, make([]byte, 64<<20))
+			written <- err
+		}()
+		var writeErr error
+		Eventually(written, 5*time.Second).Should(Receive(&writeErr))
+		Expect(writeErr).To(HaveOccurred())
+	})
+
+	It("fails to connect to a missing socket", func() {
+		_, err := NewSyslogWriter(SyslogOptions{Network: "unixgram", Address: "/nonexistent/log"})
+		Expect(err).To(HaveOccurred())
+		_, err = NewSyslogWriter(SyslogOptions{Facility: 24})
+		Expect(err).To(HaveOccurred())
+	})
+})
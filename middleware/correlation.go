@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	// log "github.com/coupa/foundation-go/logging"
+	"github.com/coupa/foundation-go/logging"
 	"github.com/gin-gonic/gin"
 	"github.com/satori/go.uuid"
 	"net/http"
@@ -9,13 +9,32 @@ import (
 
 const correlationHeader = "X-CORRELATION-ID"
 
+//Correlation sets the correlation ID of the request and stores a request logger
+//with the correlation ID and the user ID in the request context. Get it with
+//logging.FromContext(c) or logging.FromContext(c.Request.Context()).
 func Correlation() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		SetCorrelation(c.Request, c.Writer)
+		c.Request = WithCorrelation(c.Request, c.Writer)
+		c.Set(logging.ContextKey, logging.LoggerValue(c.Request.Context()))
 		c.Next()
 	}
 }
 
+//CorrelationHandler is Correlation for net/http handlers
+func CorrelationHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, WithCorrelation(req, w))
+	})
+}
+
+//WithCorrelation sets the correlation ID of the request like SetCorrelation, and
+//returns the request with a request logger in its context. See logging.FromContext.
+func WithCorrelation(req *http.Request, resp http.ResponseWriter) *http.Request {
+	SetCorrelation(req, resp)
+	ctx := logging.NewRequestContext(req.Context(), resp.Header().Get(correlationHeader), req.Header.Get(userIDHeader))
+	return req.WithContext(ctx)
+}
+
 func SetCorrelation(req *http.Request, resp http.ResponseWriter) {
 	if req.URL.Path == "/health" {
 		return
@@ -0,0 +1,68 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/coupa/foundation-go/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	. "github.com/coupa/foundation-go/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Correlation", func() {
+	Describe("Correlation middleware", func() {
+		It("stores the request logger in the context", func() {
+			logging.InitStandardLogger("VeRsIoN")
+			hook := test.NewGlobal()
+
+			engine := gin.New()
+			engine.Use(RequestLogger(false), Correlation())
+			var fromGin, fromRequest string
+			engine.GET("/test", func(c *gin.Context) {
+				fromGin = logging.CorrelationID(c)
+				fromRequest = logging.CorrelationID(c.Request.Context())
+				logging.AddField(c.Request.Context(), "tenant", "t1")
+				c.Status(http.StatusNoContent)
+			})
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-CORRELATION-ID", "abc")
+			req.Header.Set("X-USER-ID", "user1")
+			resp := httptest.NewRecorder()
+			engine.ServeHTTP(resp, req)
+			Expect(resp.Code).To(Equal(http.StatusNoContent))
+
+			Expect(fromGin).To(Equal("abc"))
+			Expect(fromRequest).To(Equal("abc"))
+			//The request log line has the field added by the handler
+			data := hook.LastEntry().Data
+			Expect(data["path"]).To(Equal("/test"))
+			Expect(data["correlation_id"]).To(Equal("abc"))
+			Expect(data["user_id"]).To(Equal("user1"))
+			Expect(data["tenant"]).To(Equal("t1"))
+		})
+	})
+
+	Describe("CorrelationHandler", func() {
+		It("stores the request logger in the context of a net/http request", func() {
+			var correlationID, userID string
+			handler := CorrelationHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				correlationID = logging.CorrelationID(req.Context())
+				userID = logging.UserID(req.Context())
+			}))
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-USER-ID", "user1")
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			Expect(correlationID).NotTo(BeEmpty())
+			Expect(correlationID).To(Equal(resp.Header().Get("X-CORRELATION-ID")))
+			Expect(userID).To(Equal("user1"))
+		})
+	})
+})
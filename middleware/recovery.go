@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/coupa/foundation-go/logging"
+	"github.com/gin-gonic/gin"
+)
+
+//Recovery recovers from the panics of the handlers and responds with 500. The
+//panic is logged with the request logger in the same structure as the errors,
+//with the message under "error" and the stack trace under "error_stacktrace".
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				logging.FromContext(c.Request.Context()).WithFields(logging.PanicFields(r)).Error("Recovered from panic")
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,39 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/coupa/foundation-go/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	. "github.com/coupa/foundation-go/middleware"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recovery", func() {
+	It("logs the panic with the request logger and responds with 500", func() {
+		logging.InitStandardLogger("VeRsIoN")
+		hook := test.NewGlobal()
+
+		engine := gin.New()
+		engine.Use(Correlation(), Recovery())
+		engine.GET("/test", func(c *gin.Context) {
+			panic("boom")
+		})
+
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-CORRELATION-ID", "abc")
+		resp := httptest.NewRecorder()
+		engine.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusInternalServerError))
+
+		entry := hook.LastEntry()
+		Expect(entry.Message).To(Equal("Recovered from panic"))
+		Expect(entry.Data["error"]).To(Equal("boom"))
+		Expect(entry.Data["error_stacktrace"]).To(ContainSubstring("recovery_test.go:"))
+		Expect(entry.Data["correlation_id"]).To(Equal("abc"))
+	})
+})
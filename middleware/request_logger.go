@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/coupa/foundation-go/logging"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 )
@@ -59,7 +60,9 @@ func RequestLogger(excludeSimpleHealth bool, excludePathsInRegexp ...string) gin
 			fields["client_version"] = value
 		}
 
-		log.WithFields(fields).Info("")
+		//The query parameters and the headers are redacted with the rules of the
+		//formatter. The request logger has the fields added during the request.
+		logging.FromContext(c.Request.Context()).WithFields(logging.RedactFields(fields)).Info("")
 	}
 }
 
@@ -49,6 +49,26 @@ var _ = Describe("RequestLogger", func() {
 			Expect(data["message"]).To(BeNil())
 		})
 
+		It("redacts the sensitive query parameters", func() {
+			logging.InitLoggerWithConfig("VeRsIoN", nil, logging.Config{Redactor: logging.DefaultRedactor()})
+			defer logging.InitStandardLogger("VeRsIoN")
+			hook := test.NewGlobal()
+
+			svr := server.Server{Engine: gin.New()}
+			svr.UseMiddleware(RequestLogger(false))
+			svr.Engine.GET("/test", handler)
+
+			req, _ := http.NewRequest("GET", "/test?token=abc&email=a@b.co&page=2", nil)
+			resp := httptest.NewRecorder()
+			svr.Engine.ServeHTTP(resp, req)
+
+			Expect(hook.LastEntry().Data["parameters"]).To(Equal(map[string]interface{}{
+				"token": logging.DefaultMask,
+				"email": []interface{}{logging.DefaultMask},
+				"page":  []interface{}{"2"},
+			}))
+		})
+
 		Context("with correlation ID", func() {
 			It("logs the correlation ID", func() {
 				logging.InitStandardLogger("VeRsIoN")
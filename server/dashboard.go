@@ -0,0 +1,143 @@
+package server
+
+import (
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/gin-gonic/gin"
+)
+
+//dashboardTemplate is a self-contained page of the detailed health for browsers
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"statusClass": func(status string) string {
+		switch health.NormalizeStatus(status) {
+		case health.OK:
+			return "ok"
+		case health.WARN:
+			return "warn"
+		}
+		return "crit"
+	},
+	"milliseconds": func(seconds float64) string {
+		return strconv.FormatFloat(seconds*1000, 'f', 1, 64) + " ms"
+	},
+	"duration": func(seconds float64) string {
+		return (time.Duration(seconds) * time.Second).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+{{if .Refresh}}<meta http-equiv="refresh" content="{{.Refresh}}">{{end}}
+<title>{{.Health.Status}} - {{if .Health.Name}}{{.Health.Name}}{{else}}Health{{end}}</title>
+<style>
+body { font-family: -apple-system, Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin: 1em 0; }
+th, td { border: 1px solid #ddd; padding: 6px 10px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+.status { display: inline-block; padding: 2px 8px; border-radius: 4px; color: #fff; font-weight: bold; }
+.ok { background: #2e7d32; }
+.warn { background: #ed6c02; }
+.crit { background: #c62828; }
+.muted { color: #777; }
+dt { font-weight: bold; float: left; clear: left; width: 8em; }
+dd { margin-left: 9em; }
+</style>
+</head>
+<body>
+{{with .Health}}
+<h1>{{if .Name}}{{.Name}}{{else}}Health{{end}} <span class="status {{statusClass .Status}}">{{.Status}}</span></h1>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{if .StatusReason}}<p class="muted">{{.StatusReason}}</p>{{end}}
+<dl>
+<dt>Version</dt><dd>{{.Version}}</dd>
+<dt>Revision</dt><dd>{{.Revision}}</dd>
+<dt>Host</dt><dd>{{.Host}}</dd>
+<dt>Uptime</dt><dd>{{duration .Uptime}}</dd>
+{{with .Build}}<dt>Go</dt><dd>{{.GoVersion}}{{if .Module}} ({{.Module}}){{end}}</dd>{{end}}
+{{with .Project}}
+{{if .Repo}}<dt>Repo</dt><dd><a href="{{.Repo}}">{{.Repo}}</a></dd>{{end}}
+{{if .Home}}<dt>Home</dt><dd><a href="{{.Home}}">{{.Home}}</a></dd>{{end}}
+{{if .Owners}}<dt>Owners</dt><dd>{{range $i, $o := .Owners}}{{if $i}}, {{end}}{{$o}}{{end}}</dd>{{end}}
+{{if .Logs}}<dt>Logs</dt><dd>{{range .Logs}}<a href="{{.}}">{{.}}</a><br>{{end}}</dd>{{end}}
+{{if .Stats}}<dt>Stats</dt><dd>{{range .Stats}}<a href="{{.}}">{{.}}</a><br>{{end}}</dd>{{end}}
+{{end}}
+</dl>
+<h2>Dependencies</h2>
+{{if .Dependencies}}
+<table>
+<tr><th>Name</th><th>Type</th><th>Criticality</th><th>Status</th><th>Response time</th><th>Version</th><th>Details</th></tr>
+{{range .Dependencies}}
+<tr>
+<td>{{.Name}}</td>
+<td>{{.Type}}</td>
+<td>{{if .Criticality}}{{.Criticality}}{{else}}required{{end}}</td>
+<td><span class="status {{statusClass .State.Status}}">{{.State.Status}}</span></td>
+<td>{{milliseconds .ResponseTime}}</td>
+<td>{{.Version}}</td>
+<td>{{.State.Details}}</td>
+</tr>
+{{end}}
+</table>
+{{else}}
+<p class="muted">There is no dependency.</p>
+{{end}}
+{{end}}
+<p class="muted">
+{{if .Refresh}}Refreshing every {{.Refresh}} seconds. <a href="{{.StopURL}}">Stop refreshing</a>{{else}}<a href="{{.RefreshURL}}">Refresh every 10 seconds</a>{{end}}
+| <a href="{{.JSONURL}}">JSON</a>
+</p>
+</body>
+</html>
+`))
+
+//wantsDashboard checks if the client prefers HTML, like a browser. The format
+//query parameter, "html" or "json", overrides the Accept header.
+func wantsDashboard(c *gin.Context) bool {
+	switch c.Query("format") {
+	case "html":
+		return true
+	case "json":
+		return false
+	}
+	return c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML
+}
+
+//renderDashboard writes the detailed health as an HTML page. The refresh query
+//parameter sets the seconds between the automatic reloads of the page.
+func renderDashboard(c *gin.Context, h *health.DetailedHealth) {
+	refresh, _ := strconv.Atoi(c.Query("refresh"))
+	if refresh < 0 {
+		refresh = 0
+	}
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	data := map[string]interface{}{
+		"Health":     h,
+		"Refresh":    refresh,
+		"StopURL":    dashboardLink(c, "refresh", ""),
+		"RefreshURL": dashboardLink(c, "refresh", "10"),
+		"JSONURL":    dashboardLink(c, "refresh", "", "format", "json"),
+	}
+	if err := dashboardTemplate.Execute(c.Writer, data); err != nil {
+		c.Error(err)
+	}
+}
+
+//dashboardLink returns the relative URL of the page with the query parameters
+//set to the values, or removed if a value is empty. The other query parameters,
+//like the check and type filters, are kept.
+func dashboardLink(c *gin.Context, pairs ...string) string {
+	query := c.Request.URL.Query()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			query.Del(pairs[i])
+		} else {
+			query.Set(pairs[i], pairs[i+1])
+		}
+	}
+	return "?" + query.Encode()
+}
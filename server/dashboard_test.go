@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/gin-gonic/gin"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dashboard", func() {
+	var svr Server
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp := httptest.NewRecorder()
+		svr.Engine.ServeHTTP(resp, req)
+		return resp
+	}
+
+	BeforeEach(func() {
+		svr = Server{
+			Engine:  gin.New(),
+			AppInfo: &health.AppInfo{AppName: "some-app", Version: "1.0.0"},
+			ProjectInfo: &health.ProjectInfo{
+				Repo:   "https://some.repo",
+				Owners: []string{"owner1", "owner2"},
+				Logs:   []string{"https://some.logs"},
+			},
+		}
+		svr.RegisterDetailedHealth("/v1", "Some <app>", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{NewStatusCheck("mysql", health.OK), health.Optional(NewStatusCheck("redis", health.CRIT))},
+		})
+	})
+
+	It("renders HTML for browsers", func() {
+		resp := get("/v1/health/detailed", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("text/html; charset=utf-8"))
+		Expect(resp.Header().Get("Vary")).To(Equal("Accept"))
+		body := resp.Body.String()
+		Expect(body).To(ContainSubstring(`<title>WARN - some-app</title>`))
+		Expect(body).To(ContainSubstring(`<p>Some &lt;app&gt;</p>`))
+		Expect(body).To(ContainSubstring(`<a href="https://some.repo">https://some.repo</a>`))
+		Expect(body).To(ContainSubstring(`owner1, owner2`))
+		Expect(body).To(ContainSubstring(`<a href="https://some.logs">`))
+		Expect(body).To(ContainSubstring(`<td>mysql</td>`))
+		Expect(body).To(ContainSubstring(`<span class="status crit">CRIT</span>`))
+		Expect(body).To(ContainSubstring(`<td>optional</td>`))
+		Expect(body).NotTo(ContainSubstring(`http-equiv="refresh"`))
+
+		body = get("/v1/health/detailed?refresh=5", "text/html").Body.String()
+		Expect(body).To(ContainSubstring(`<meta http-equiv="refresh" content="5">`))
+	})
+
+	It("keeps the filters in the links", func() {
+		body := get("/v1/health/detailed?check=mysql&type=service", "text/html").Body.String()
+		Expect(body).To(ContainSubstring(`<a href="?check=mysql&amp;refresh=10&amp;type=service">Refresh every 10 seconds</a>`))
+		Expect(body).To(ContainSubstring(`<a href="?check=mysql&amp;format=json&amp;type=service">JSON</a>`))
+
+		body = get("/v1/health/detailed?check=mysql&refresh=5", "text/html").Body.String()
+		Expect(body).To(ContainSubstring(`<a href="?check=mysql">Stop refreshing</a>`))
+	})
+
+	It("keeps the JSON payload for the other clients", func() {
+		decode := func(resp *httptest.ResponseRecorder) map[string]interface{} {
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Header().Get("Content-Type")).To(HavePrefix("application/json"))
+			var m map[string]interface{}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &m)).To(Succeed())
+			delete(m, "uptime")
+			//The dependencies are keyed by name since they finish in any order
+			deps := map[string]interface{}{}
+			for _, d := range m["dependencies"].([]interface{}) {
+				delete(d.(map[string]interface{}), "responseTime")
+				deps[d.(map[string]interface{})["name"].(string)] = d
+			}
+			m["dependencies"] = deps
+			return m
+		}
+		Expect(get("/v1/health/detailed", "").Header().Get("Vary")).To(Equal("Accept"))
+		plain := decode(get("/v1/health/detailed", ""))
+		Expect(plain["status"]).To(Equal(health.WARN))
+		Expect(decode(get("/v1/health/detailed", "application/json"))).To(Equal(plain))
+		Expect(decode(get("/v1/health/detailed", "*/*"))).To(Equal(plain))
+		Expect(decode(get("/v1/health/detailed?format=json", "text/html"))).To(Equal(plain))
+	})
+})
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	//DrainingDetails is the details of the simple health while the server is draining
+	DrainingDetails = "draining"
+)
+
+//ShutdownOptions control RunWithGracefulShutdown
+type ShutdownOptions struct {
+	//DrainPeriod is how long the server keeps serving while draining before it
+	//shuts down, so that the load balancer sees the failing health and stops
+	//sending new requests
+	DrainPeriod time.Duration
+	//Timeout limits how long the shutdown waits for the in-flight requests after
+	//the drain period. There is no limit if it is 0.
+	Timeout time.Duration
+	//Signals start the shutdown. They default to os.Interrupt and syscall.SIGTERM.
+	Signals []os.Signal
+}
+
+//SetDraining turns the drain mode on or off. While draining, the simple health
+//and the readiness respond with 503 and CRIT, the detailed health and the
+//Prometheus health_status are CRIT, and the gRPC health of the server is
+//NOT_SERVING, while the other routes keep serving the in-flight and new requests.
+func (s *Server) SetDraining(draining bool) {
+	var v int32
+	if draining {
+		v = 1
+	}
+	if atomic.SwapInt32(&s.draining, v) != v {
+		log.WithField("draining", draining).Info("Drain mode changed")
+	}
+}
+
+//IsDraining checks if the server is in the drain mode
+func (s *Server) IsDraining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
+//RegisterDrainEndpoint registers the admin endpoint of the drain mode at path,
+//which defaults to "/admin/drain". GET returns the drain mode, PUT turns it on
+//and DELETE turns it off. auth must protect the endpoint, such as gin.BasicAuth.
+func (s *Server) RegisterDrainEndpoint(path string, auth gin.HandlerFunc) {
+	if auth == nil {
+		panic("The drain endpoint must be protected by an auth handler")
+	}
+	if path == "" {
+		path = "/admin/drain"
+	}
+	s.Engine.GET(path, auth, s.drainStatus)
+	s.Engine.PUT(path, auth, func(c *gin.Context) {
+		s.SetDraining(true)
+		s.drainStatus(c)
+	})
+	s.Engine.DELETE(path, auth, func(c *gin.Context) {
+		s.SetDraining(false)
+		s.drainStatus(c)
+	})
+}
+
+func (s *Server) drainStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"draining": s.IsDraining()})
+}
+
+//ToggleDrainOnSignal toggles the drain mode every time one of the signals is
+//received. The signal is syscall.SIGUSR1 if none is given. Call the returned
+//function to stop it.
+func (s *Server) ToggleDrainOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		//signal.Notify without signals would relay all of them, including SIGURG
+		//that the runtime uses for preemption
+		sigs = []os.Signal{syscall.SIGUSR1}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				s.SetDraining(!s.IsDraining())
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+//GracefulShutdown drains the server for drainPeriod and then shuts down srv,
+//waiting for the in-flight requests to finish or ctx to expire
+func (s *Server) GracefulShutdown(ctx context.Context, srv *http.Server, drainPeriod time.Duration) error {
+	s.SetDraining(true)
+	timer := time.NewTimer(drainPeriod)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+	}
+	return srv.Shutdown(ctx)
+}
+
+//RunWithGracefulShutdown serves the engine at addr until one of the shutdown
+//signals is received, and then shuts down with GracefulShutdown
+func (s *Server) RunWithGracefulShutdown(addr string, opts ShutdownOptions) error {
+	srv := &http.Server{Addr: addr, Handler: s.Engine}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	sigs := opts.Signals
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	defer signal.Stop(ch)
+
+	select {
+	case err := <-errCh:
+		return err
+	case sig := <-ch:
+		log.WithField("signal", sig.String()).Info("Shutting down the server")
+	}
+
+	ctx := context.Background()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.DrainPeriod+opts.Timeout)
+		defer cancel()
+	}
+	if err := s.GracefulShutdown(ctx, srv, opts.DrainPeriod); err != nil {
+		return err
+	}
+	if err := <-errCh; err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/gin-gonic/gin"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drain mode", func() {
+	var svr *Server
+
+	get := func(method, path string, auth bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		if auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		resp := httptest.NewRecorder()
+		svr.Engine.ServeHTTP(resp, req)
+		return resp
+	}
+
+	BeforeEach(func() {
+		svr = &Server{Engine: gin.New(), AppInfo: &health.AppInfo{Version: "v1"}}
+		svr.RegisterSimpleHealth()
+		svr.RegisterReadiness()
+		svr.RegisterDetailedHealth("/v1", "v1", &health.AdditionalHealthData{})
+		svr.RegisterPrometheusMetrics("")
+		svr.RegisterDrainEndpoint("", gin.BasicAuth(gin.Accounts{"admin": "secret"}))
+	})
+
+	It("fails the simple health while draining", func() {
+		Expect(get("GET", "/health", false).Code).To(Equal(http.StatusOK))
+
+		svr.SetDraining(true)
+		Expect(svr.IsDraining()).To(BeTrue())
+		resp := get("GET", "/health", false)
+		Expect(resp.Code).To(Equal(http.StatusServiceUnavailable))
+		var h health.SimpleHealth
+		json.Unmarshal(resp.Body.Bytes(), &h)
+		Expect(h).To(Equal(health.SimpleHealth{Status: health.CRIT, Version: "v1", Details: DrainingDetails}))
+
+		svr.SetDraining(false)
+		Expect(get("GET", "/health", false).Code).To(Equal(http.StatusOK))
+	})
+
+	It("fails the readiness, the detailed health and the metrics while draining", func() {
+		Expect(get("GET", "/health/ready", false).Code).To(Equal(http.StatusOK))
+		Expect(get("GET", "/metrics", false).Body.String()).To(ContainSubstring("health_status 0\n"))
+
+		svr.SetDraining(true)
+		resp := get("GET", "/health/ready", false)
+		Expect(resp.Code).To(Equal(http.StatusServiceUnavailable))
+		var h health.SimpleHealth
+		json.Unmarshal(resp.Body.Bytes(), &h)
+		Expect(h.Details).To(Equal(DrainingDetails))
+
+		var dh health.DetailedHealth
+		json.Unmarshal(get("GET", "/v1/health/detailed", false).Body.Bytes(), &dh)
+		Expect(dh.Status).To(Equal(health.CRIT))
+		Expect(dh.StatusReason).To(Equal("CRIT: the server is draining"))
+
+		metrics := get("GET", "/metrics", false).Body.String()
+		Expect(metrics).To(ContainSubstring("health_status 2\n"))
+		Expect(metrics).To(ContainSubstring("health_draining 1\n"))
+	})
+
+	It("toggles the drain mode with the protected admin endpoint", func() {
+		Expect(get("PUT", "/admin/drain", false).Code).To(Equal(http.StatusUnauthorized))
+		Expect(svr.IsDraining()).To(BeFalse())
+
+		resp := get("PUT", "/admin/drain", true)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(MatchJSON(`{"draining":true}`))
+		Expect(svr.IsDraining()).To(BeTrue())
+		Expect(get("GET", "/admin/drain", true).Body.String()).To(MatchJSON(`{"draining":true}`))
+
+		get("DELETE", "/admin/drain", true)
+		Expect(svr.IsDraining()).To(BeFalse())
+	})
+
+	It("requires an auth handler for the admin endpoint", func() {
+		Expect(func() { svr.RegisterDrainEndpoint("/drain", nil) }).To(Panic())
+	})
+
+	It("toggles the drain mode on the signal", func() {
+		stop := svr.ToggleDrainOnSignal(syscall.SIGHUP)
+		defer stop()
+
+		p, _ := os.FindProcess(os.Getpid())
+		p.Signal(syscall.SIGHUP)
+		Eventually(svr.IsDraining).Should(BeTrue())
+		p.Signal(syscall.SIGHUP)
+		Eventually(svr.IsDraining).Should(BeFalse())
+	})
+
+	It("toggles the drain mode on SIGUSR1 only without signals", func() {
+		stop := svr.ToggleDrainOnSignal()
+		defer stop()
+
+		p, _ := os.FindProcess(os.Getpid())
+		p.Signal(syscall.SIGWINCH)
+		Consistently(svr.IsDraining, 50*time.Millisecond).Should(BeFalse())
+		p.Signal(syscall.SIGUSR1)
+		Eventually(svr.IsDraining).Should(BeTrue())
+	})
+
+	It("drains before shutting down and lets the in-flight requests finish", func() {
+		started := make(chan struct{})
+		svr.Engine.GET("/slow", func(c *gin.Context) {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			c.String(http.StatusOK, "done")
+		})
+		ts := httptest.NewServer(svr.Engine)
+
+		result := make(chan string, 1)
+		go func() {
+			resp, err := http.Get(ts.URL + "/slow")
+			if err != nil {
+				result <- err.Error()
+				return
+			}
+			resp.Body.Close()
+			result <- resp.Status
+		}()
+		<-started
+
+		healthDuringDrain := make(chan int, 1)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			resp, err := http.Get(ts.URL + "/health")
+			if err == nil {
+				resp.Body.Close()
+				healthDuringDrain <- resp.StatusCode
+			}
+		}()
+
+		Expect(svr.GracefulShutdown(context.Background(), ts.Config, 30*time.Millisecond)).To(Succeed())
+		Expect(<-result).To(Equal("200 OK"))
+		Expect(<-healthDuringDrain).To(Equal(http.StatusServiceUnavailable))
+	})
+})
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/gin-gonic/gin"
+)
+
+//cacheKey identifies a check by its index in the checks of the key, like the
+//version group, since the names of the checks are not unique
+type cacheKey struct {
+	key   string
+	index int
+}
+
+//cachedResult is a result cached for HealthCacheTTL with the name of the check,
+//so that a result is not reused if the checks are changed
+type cachedResult struct {
+	health.CheckedDependency
+	name string
+}
+
+//filterChecks returns the indexes of the checks with the names and the types.
+//Empty names or types do not filter. It fails if any name is unknown.
+func filterChecks(checks []health.HealthChecker, names, types []string) ([]int, error) {
+	if len(names) == 0 && len(types) == 0 {
+		return allIndexes(checks), nil
+	}
+	known := map[string]bool{}
+	for _, hc := range checks {
+		known[hc.GetName()] = true
+	}
+	wanted := map[string]bool{}
+	var unknown []string
+	for _, name := range names {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+		wanted[name] = true
+	}
+	if len(unknown) > 0 {
+		valid := make([]string, 0, len(known))
+		for name := range known {
+			valid = append(valid, name)
+		}
+		sort.Strings(valid)
+		return nil, errors.New("Unknown checks: " + strings.Join(unknown, ", ") + ". Valid checks are: " + strings.Join(valid, ", "))
+	}
+
+	var filtered []int
+	for i, hc := range checks {
+		if len(names) > 0 && !wanted[hc.GetName()] {
+			continue
+		}
+		if len(types) > 0 && !containsFold(types, hc.GetType()) {
+			continue
+		}
+		filtered = append(filtered, i)
+	}
+	return filtered, nil
+}
+
+//allIndexes returns the indexes of all the checks
+func allIndexes(checks []health.HealthChecker) []int {
+	indexes := make([]int, len(checks))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes
+}
+
+//runChecks returns the results of the checks at the indexes in the same order as
+//the indexes, with when they were checked. The checks are cached by the key, like
+//the version group, and their indexes. The results cached within HealthCacheTTL
+//are reused unless fresh is true, and the other checks are run and recorded.
+func (s *Server) runChecks(key string, checks []health.HealthChecker, indexes []int, fresh bool) []health.CheckedDependency {
+	results := make([]health.CheckedDependency, len(indexes))
+	var stale []health.HealthChecker
+	var staleResults []int
+	now := time.Now()
+	s.cacheMu.Lock()
+	for i, index := range indexes {
+		hc := checks[index]
+		cr, found := s.cache[cacheKey{key: key, index: index}]
+		if s.HealthCacheTTL > 0 && !fresh && found && cr.name == hc.GetName() && now.Sub(cr.CheckedAt) < s.HealthCacheTTL {
+			results[i] = cr.CheckedDependency
+		} else {
+			stale = append(stale, hc)
+			staleResults = append(staleResults, i)
+		}
+	}
+	s.cacheMu.Unlock()
+
+	deps := s.checkDependencies(stale)
+	s.recordResults(deps)
+	now = time.Now()
+	s.cacheMu.Lock()
+	if s.cache == nil {
+		s.cache = map[cacheKey]cachedResult{}
+	}
+	for i, di := range deps {
+		cd := health.CheckedDependency{DependencyInfo: di, CheckedAt: now}
+		results[staleResults[i]] = cd
+		if s.HealthCacheTTL > 0 {
+			s.cache[cacheKey{key: key, index: indexes[staleResults[i]]}] = cachedResult{CheckedDependency: cd, name: stale[i].GetName()}
+		}
+	}
+	s.cacheMu.Unlock()
+	return results
+}
+
+//dependencyInfos returns the results without the times they were checked
+func dependencyInfos(results []health.CheckedDependency) []*health.DependencyInfo {
+	deps := make([]*health.DependencyInfo, len(results))
+	for i, cd := range results {
+		deps[i] = cd.DependencyInfo
+	}
+	return deps
+}
+
+//queryList returns the values of a query parameter that can be repeated or comma-separated
+func queryList(c *gin.Context, key string) []string {
+	var list []string
+	for _, v := range c.QueryArray(key) {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
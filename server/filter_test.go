@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/gin-gonic/gin"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Detailed health filtering", func() {
+	var (
+		svr   Server
+		mysql *CountingCheck
+	)
+
+	get := func(path string) (int, *health.DetailedHealth) {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		svr.Engine.ServeHTTP(resp, req)
+		h, _ := health.DecodeHealth(resp.Body)
+		return resp.Code, h
+	}
+	names := func(h *health.DetailedHealth) []string {
+		var list []string
+		for _, d := range h.Dependencies {
+			list = append(list, d.Name)
+		}
+		return list
+	}
+
+	BeforeEach(func() {
+		mysql = &CountingCheck{StatusCheck: NewStatusCheck("mysql", health.OK)}
+		svr = Server{Engine: gin.New(), ProjectInfo: &health.ProjectInfo{Repo: "https://some.repo"}}
+		svr.RegisterDetailedHealth("/v1", "v1", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{
+				mysql,
+				NewStatusCheck("redis", health.OK),
+				health.WebCheck{Name: "web", Type: health.TypeThirdParty, URL: "abc"},
+			},
+		})
+	})
+
+	It("runs only the named checks", func() {
+		code, h := get("/v1/health/detailed?check=mysql&check=redis")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(names(h)).To(ConsistOf("mysql", "redis"))
+		Expect(h.Status).To(Equal(health.OK))
+
+		_, h = get("/v1/health/detailed?check=redis")
+		Expect(names(h)).To(Equal([]string{"redis"}))
+		Expect(mysql.Count()).To(Equal(int32(1)))
+	})
+
+	It("runs only the checks of the types", func() {
+		_, h := get("/v1/health/detailed?type=Service")
+		Expect(names(h)).To(ConsistOf("mysql", "redis"))
+
+		_, h = get("/v1/health/detailed?type=third-party&check=web,mysql")
+		Expect(names(h)).To(Equal([]string{"web"}))
+		Expect(h.Status).To(Equal(health.CRIT))
+	})
+
+	It("returns 400 for unknown checks", func() {
+		req, _ := http.NewRequest("GET", "/v1/health/detailed?check=mysql,unknown", nil)
+		resp := httptest.NewRecorder()
+		svr.Engine.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		Expect(resp.Body.String()).To(MatchJSON(`{"error":"Unknown checks: unknown. Valid checks are: mysql, redis, web"}`))
+		Expect(mysql.Count()).To(Equal(int32(0)))
+	})
+
+	It("omits the project info when not verbose", func() {
+		_, h := get("/v1/health/detailed?check=redis")
+		Expect(h.Project.Repo).To(Equal("https://some.repo"))
+		_, h = get("/v1/health/detailed?check=redis&verbose=false")
+		Expect(h.Project).To(BeNil())
+	})
+
+	It("uses the cache unless fresh is requested", func() {
+		svr.HealthCacheTTL = time.Minute
+		get("/v1/health/detailed?check=mysql")
+		_, h := get("/v1/health/detailed?check=mysql")
+		Expect(h.Dependencies).To(HaveLen(1))
+		Expect(mysql.Count()).To(Equal(int32(1)))
+
+		get("/v1/health/detailed?check=mysql&fresh=true")
+		Expect(mysql.Count()).To(Equal(int32(2)))
+
+		svr.HealthCacheTTL = time.Nanosecond
+		time.Sleep(time.Millisecond)
+		get("/v1/health/detailed?check=mysql")
+		Expect(mysql.Count()).To(Equal(int32(3)))
+	})
+
+	It("caches the checks with the same name separately", func() {
+		svr.HealthCacheTTL = time.Minute
+		svr.RegisterDetailedHealth("/v2", "v2", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{NewStatusCheck("db", health.OK), NewStatusCheck("db", health.CRIT)},
+		})
+		for i := 0; i < 2; i++ {
+			_, h := get("/v2/health/detailed")
+			Expect(h.Dependencies).To(HaveLen(2))
+			Expect(h.Dependencies[0].State.Status).To(Equal(health.OK))
+			Expect(h.Dependencies[1].State.Status).To(Equal(health.CRIT))
+		}
+	})
+})
+
+//CountingCheck counts how many times it is checked
+type CountingCheck struct {
+	*StatusCheck
+	count int32
+}
+
+func (cc *CountingCheck) Check() *health.DependencyInfo {
+	atomic.AddInt32(&cc.count, 1)
+	return cc.StatusCheck.Check()
+}
+
+func (cc *CountingCheck) Count() int32 {
+	return atomic.LoadInt32(&cc.count)
+}
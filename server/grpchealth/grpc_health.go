@@ -0,0 +1,161 @@
+//Package grpchealth serves the health checks of a server.Server with the standard
+//grpc.health.v1.Health service. It is separate from the server package so that
+//only its users depend on gRPC.
+package grpchealth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/coupa/foundation-go/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	//DefaultWatchInterval is how often the status of a watched service is re-evaluated
+	DefaultWatchInterval = 10 * time.Second
+)
+
+//HealthServer implements the standard grpc.health.v1.Health service with the
+//health checks registered on a server.Server. The service names are those of
+//server.Server.ServiceStatus. OK and WARN are SERVING, and CRIT is NOT_SERVING.
+type HealthServer struct {
+	healthpb.UnimplementedHealthServer
+	//WatchInterval overrides DefaultWatchInterval when it is > 0
+	WatchInterval time.Duration
+
+	server  *server.Server
+	mu      sync.Mutex
+	watches map[string]*watch
+}
+
+//watch evaluates the status of a service once every interval for all its watchers
+type watch struct {
+	watchers map[chan healthpb.HealthCheckResponse_ServingStatus]bool
+	last     healthpb.HealthCheckResponse_ServingStatus
+	known    bool
+	stop     chan struct{}
+}
+
+//New creates a gRPC health server backed by the health checks of s
+func New(s *server.Server) *HealthServer {
+	return &HealthServer{server: s, watches: map[string]*watch{}}
+}
+
+//Register registers the gRPC health service of s on gs. It should be called after
+//the detailed health is registered.
+func Register(gs *grpc.Server, s *server.Server) *HealthServer {
+	hs := New(s)
+	healthpb.RegisterHealthServer(gs, hs)
+	return hs
+}
+
+//Check returns the serving status of the requested service. It fails with the
+//NOT_FOUND code for an unknown service.
+func (hs *HealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st := hs.servingStatus(req.GetService())
+	if st == healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+//Watch sends the serving status of the requested service immediately and then
+//whenever it changes. An unknown service is SERVICE_UNKNOWN. The watchers of a
+//service share one evaluation of its status every interval.
+func (hs *HealthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	updates, cancel := hs.subscribe(req.GetService())
+	defer cancel()
+	for {
+		select {
+		case st := <-updates:
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "Stream has ended")
+		}
+	}
+}
+
+//subscribe returns the channel of the status changes of the service, starting
+//the evaluation of its status for the first watcher. Call cancel to unsubscribe.
+func (hs *HealthServer) subscribe(service string) (<-chan healthpb.HealthCheckResponse_ServingStatus, func()) {
+	updates := make(chan healthpb.HealthCheckResponse_ServingStatus, 1)
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if hs.watches == nil {
+		hs.watches = map[string]*watch{}
+	}
+	w := hs.watches[service]
+	if w == nil {
+		w = &watch{watchers: map[chan healthpb.HealthCheckResponse_ServingStatus]bool{}, stop: make(chan struct{})}
+		hs.watches[service] = w
+		go hs.evaluate(service, w)
+	}
+	w.watchers[updates] = true
+	if w.known {
+		updates <- w.last
+	}
+
+	return updates, func() {
+		hs.mu.Lock()
+		defer hs.mu.Unlock()
+		delete(w.watchers, updates)
+		if len(w.watchers) == 0 && hs.watches[service] == w {
+			delete(hs.watches, service)
+			close(w.stop)
+		}
+	}
+}
+
+//evaluate evaluates the status of the service every interval and sends it to the
+//watchers when it changes, until the last watcher unsubscribes
+func (hs *HealthServer) evaluate(service string, w *watch) {
+	interval := hs.WatchInterval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		st := hs.servingStatus(service)
+		hs.mu.Lock()
+		if !w.known || st != w.last {
+			w.known, w.last = true, st
+			for updates := range w.watchers {
+				//A watcher that has not sent the previous status gets only the latest
+				select {
+				case <-updates:
+				default:
+				}
+				updates <- st
+			}
+		}
+		hs.mu.Unlock()
+
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+//servingStatus converts the status of the service. It is SERVICE_UNKNOWN if the
+//service is unknown.
+func (hs *HealthServer) servingStatus(service string) healthpb.HealthCheckResponse_ServingStatus {
+	st, found := hs.server.ServiceStatus(service)
+	if !found {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	if st == health.CRIT {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,178 @@
+package grpchealth_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/coupa/foundation-go/server"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	. "github.com/coupa/foundation-go/server/grpchealth"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HealthServer", func() {
+	var (
+		svr    *server.Server
+		gs     *grpc.Server
+		hs     *HealthServer
+		conn   *grpc.ClientConn
+		client healthpb.HealthClient
+		check  *statusCheck
+	)
+
+	BeforeEach(func() {
+		check = &statusCheck{name: "mysql", status: health.OK}
+		svr = &server.Server{Engine: gin.New()}
+		svr.RegisterDetailedHealth("/v1", "v1", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{check, &statusCheck{name: "redis", status: health.WARN}},
+		})
+
+		lis := bufconn.Listen(1024 * 1024)
+		gs = grpc.NewServer()
+		hs = Register(gs, svr)
+		hs.WatchInterval = 10 * time.Millisecond
+		go gs.Serve(lis)
+
+		var err error
+		conn, err = grpc.Dial("bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) { return lis.Dial() }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		client = healthpb.NewHealthClient(conn)
+	})
+
+	AfterEach(func() {
+		conn.Close()
+		gs.Stop()
+	})
+
+	Describe("Check", func() {
+		It("reports the server, the version groups and the dependency checks", func() {
+			for _, service := range []string{"", "v1", "/v1", "mysql", "redis"} {
+				resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Status).To(Equal(healthpb.HealthCheckResponse_SERVING), service)
+			}
+
+			check.setStatus(health.CRIT)
+			for _, service := range []string{"v1", "mysql"} {
+				resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Status).To(Equal(healthpb.HealthCheckResponse_NOT_SERVING), service)
+			}
+		})
+
+		It("reports the server as NOT_SERVING while draining", func() {
+			svr.SetDraining(true)
+			resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: ""})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Status).To(Equal(healthpb.HealthCheckResponse_NOT_SERVING))
+
+			resp, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "mysql"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Status).To(Equal(healthpb.HealthCheckResponse_SERVING))
+		})
+
+		It("returns NOT_FOUND for an unknown service", func() {
+			_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+	})
+
+	Describe("Watch", func() {
+		It("sends the status and its changes to all the watchers", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var streams []healthpb.Health_WatchClient
+			for i := 0; i < 2; i++ {
+				stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "mysql"})
+				Expect(err).NotTo(HaveOccurred())
+				resp, err := stream.Recv()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Status).To(Equal(healthpb.HealthCheckResponse_SERVING))
+				streams = append(streams, stream)
+			}
+
+			check.setStatus(health.CRIT)
+			for _, stream := range streams {
+				resp, err := stream.Recv()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Status).To(Equal(healthpb.HealthCheckResponse_NOT_SERVING))
+			}
+		})
+
+		It("evaluates the status once per interval for all the watchers", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			for i := 0; i < 10; i++ {
+				stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "mysql"})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = stream.Recv()
+				Expect(err).NotTo(HaveOccurred())
+			}
+			before := check.count()
+			time.Sleep(100 * time.Millisecond)
+			//About 10 evaluations, instead of 100 with one per watcher
+			Expect(check.count() - before).To(BeNumerically("<=", 20))
+		})
+
+		It("sends SERVICE_UNKNOWN for an unknown service", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+			Expect(err).NotTo(HaveOccurred())
+
+			resp, err := stream.Recv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Status).To(Equal(healthpb.HealthCheckResponse_SERVICE_UNKNOWN))
+		})
+	})
+})
+
+//statusCheck reports a status that can be changed concurrently, and counts how
+//many times it is checked
+type statusCheck struct {
+	name string
+
+	mu      sync.Mutex
+	status  string
+	checked int32
+}
+
+func (sc *statusCheck) setStatus(status string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.status = status
+}
+
+func (sc *statusCheck) count() int32 {
+	return atomic.LoadInt32(&sc.checked)
+}
+
+func (sc *statusCheck) Check() *health.DependencyInfo {
+	atomic.AddInt32(&sc.checked, 1)
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return &health.DependencyInfo{Name: sc.name, Type: health.TypeService, State: health.DependencyState{Status: sc.status}}
+}
+
+func (sc *statusCheck) GetName() string {
+	return sc.name
+}
+
+func (sc *statusCheck) GetType() string {
+	return health.TypeService
+}
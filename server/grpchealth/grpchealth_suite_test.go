@@ -0,0 +1,13 @@
+package grpchealth_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGRPCHealth(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GRPC Health Suite")
+}
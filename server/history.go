@@ -0,0 +1,81 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/gin-gonic/gin"
+)
+
+//DependencyHistory is the history of a dependency in the /health/history response
+type DependencyHistory struct {
+	Name    string                `json:"name"`
+	Summary health.HistorySummary `json:"summary"`
+	Entries []health.HistoryEntry `json:"entries"`
+}
+
+//RegisterHealthHistory registers /health/history, which serves the recorded
+//results of the dependency checks with their summary. It creates the history with
+//health.DefaultHistorySize if s.History is nil.
+//The query parameters are:
+//  - name: the dependency names, which can be repeated or comma-separated. All
+//    dependencies are returned if it is not set.
+//  - from, to: the time range in RFC 3339, like "2020-01-02T15:04:05Z".
+func (s *Server) RegisterHealthHistory() {
+	s.RegisterHealthHistoryOn(s.Engine)
+}
+
+//RegisterHealthHistoryOn registers /health/history on the router, which can be
+//a router group with any base path.
+func (s *Server) RegisterHealthHistoryOn(r gin.IRouter) {
+	if s.History == nil {
+		s.History = health.NewHistory(health.DefaultHistorySize)
+	}
+	r.GET("/health/history", s.healthHistory)
+}
+
+func (s *Server) healthHistory(c *gin.Context) {
+	from, err := parseTimeParam(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := parseTimeParam(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	names := queryList(c, "name")
+	if len(names) == 0 {
+		names = s.History.Names()
+	}
+
+	deps := []DependencyHistory{}
+	for _, name := range names {
+		entries := s.History.Entries(name, from, to)
+		if entries == nil {
+			entries = []health.HistoryEntry{}
+		}
+		deps = append(deps, DependencyHistory{
+			Name:    name,
+			Summary: health.SummarizeHistory(entries),
+			Entries: entries,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"dependencies": deps})
+}
+
+func parseTimeParam(c *gin.Context, key string) (time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return t, errors.New("Invalid `" + key + "` time: " + err.Error())
+	}
+	return t, nil
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/gin-gonic/gin"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health history", func() {
+	var svr Server
+
+	get := func(path string) (int, map[string][]DependencyHistory) {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		svr.Engine.ServeHTTP(resp, req)
+		var body map[string][]DependencyHistory
+		json.Unmarshal(resp.Body.Bytes(), &body)
+		return resp.Code, body
+	}
+
+	BeforeEach(func() {
+		svr = Server{Engine: gin.New()}
+		svr.RegisterDetailedHealth("/v1", "v1", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{NewStatusCheck("mysql", health.OK), NewStatusCheck("redis", health.CRIT)},
+		})
+		svr.RegisterHealthHistory()
+	})
+
+	It("records the results of the detailed health", func() {
+		get("/v1/health/detailed")
+		get("/v1/health/detailed")
+
+		code, body := get("/health/history")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body["dependencies"]).To(HaveLen(2))
+		mysql := body["dependencies"][0]
+		Expect(mysql.Name).To(Equal("mysql"))
+		Expect(mysql.Entries).To(HaveLen(2))
+		Expect(mysql.Entries[0].Status).To(Equal(health.OK))
+		Expect(mysql.Summary.Count).To(Equal(2))
+		Expect(mysql.Summary.Availability).To(Equal(100.0))
+		Expect(body["dependencies"][1].Summary.Availability).To(Equal(0.0))
+	})
+
+	It("filters by the names and the time range", func() {
+		get("/v1/health/detailed")
+
+		_, body := get("/health/history?name=redis,unknown")
+		Expect(body["dependencies"]).To(HaveLen(2))
+		Expect(body["dependencies"][0].Name).To(Equal("redis"))
+		Expect(body["dependencies"][0].Entries).To(HaveLen(1))
+		Expect(body["dependencies"][1].Name).To(Equal("unknown"))
+		Expect(body["dependencies"][1].Entries).To(BeEmpty())
+
+		future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		_, body = get("/health/history?name=redis&from=" + future)
+		Expect(body["dependencies"][0].Entries).To(BeEmpty())
+		Expect(body["dependencies"][0].Summary.Count).To(Equal(0))
+
+		code, _ := get("/health/history?to=yesterday")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+})
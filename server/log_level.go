@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/coupa/foundation-go/logging"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+//RegisterLogLevelEndpoint registers the admin endpoint of the log levels at path,
+//which defaults to "/admin/log-level". auth must protect the endpoint, such as
+//gin.BasicAuth.
+//  - GET returns the level of the root logger and the levels of the named loggers.
+//  - PUT sets a level with the query parameters "level", like "debug", "logger",
+//    the name of a named logger or empty for the root logger, and "expiresIn",
+//    like "15m", after which the level reverts.
+//  - DELETE with the query parameter "logger" makes the named logger use the
+//    level of the root logger again.
+func (s *Server) RegisterLogLevelEndpoint(path string, auth gin.HandlerFunc) {
+	if auth == nil {
+		panic("The log level endpoint must be protected by an auth handler")
+	}
+	if path == "" {
+		path = "/admin/log-level"
+	}
+	s.Engine.GET(path, auth, logLevels)
+	s.Engine.PUT(path, auth, setLogLevel)
+	s.Engine.DELETE(path, auth, resetLogLevel)
+}
+
+func logLevels(c *gin.Context) {
+	c.JSON(http.StatusOK, logging.Levels())
+}
+
+func setLogLevel(c *gin.Context) {
+	var expiresIn time.Duration
+	if v := c.Query("expiresIn"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expiresIn `" + v + "`. It must be a duration like 15m"})
+			return
+		}
+		expiresIn = d
+	}
+	name := c.Query("logger")
+	if err := logging.SetLevelFor(name, c.Query("level"), expiresIn); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.WithFields(log.Fields{"logger": name, "log_level": c.Query("level"), "expires_in": expiresIn.String()}).Info("Log level changed")
+	logLevels(c)
+}
+
+func resetLogLevel(c *gin.Context) {
+	name := c.Query("logger")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The logger is required"})
+		return
+	}
+	logging.ResetLevel(name)
+	log.WithField("logger", name).Info("Log level reset")
+	logLevels(c)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/coupa/foundation-go/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Log level endpoint", func() {
+	var svr *Server
+
+	request := func(method, path string, auth bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		if auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		resp := httptest.NewRecorder()
+		svr.Engine.ServeHTTP(resp, req)
+		return resp
+	}
+
+	BeforeEach(func() {
+		svr = &Server{Engine: gin.New()}
+		svr.RegisterLogLevelEndpoint("", gin.BasicAuth(gin.Accounts{"admin": "secret"}))
+	})
+
+	AfterEach(func() {
+		logging.ResetLevel("kafka")
+		logging.SetLevel("", "info")
+	})
+
+	It("reads and changes the log levels", func() {
+		Expect(request("PUT", "/admin/log-level?level=debug", false).Code).To(Equal(http.StatusUnauthorized))
+
+		resp := request("PUT", "/admin/log-level?level=debug", true)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(MatchJSON(`{"level":"debug"}`))
+		Expect(logrus.GetLevel()).To(Equal(logrus.DebugLevel))
+
+		resp = request("PUT", "/admin/log-level?level=warn&logger=kafka&expiresIn=10m", true)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		status := logging.Levels()
+		Expect(status.Loggers["kafka"].Level).To(Equal("warning"))
+		Expect(status.Loggers["kafka"].ExpiresAt).NotTo(BeNil())
+		Expect(request("GET", "/admin/log-level", true).Body.String()).To(ContainSubstring(`"kafka":{"level":"warning","expiresAt":`))
+
+		request("DELETE", "/admin/log-level?logger=kafka", true)
+		Expect(logging.Level("kafka")).To(Equal("debug"))
+	})
+
+	It("rejects invalid requests", func() {
+		Expect(request("PUT", "/admin/log-level?level=loud", true).Code).To(Equal(http.StatusBadRequest))
+		Expect(request("PUT", "/admin/log-level?level=debug&expiresIn=soon", true).Code).To(Equal(http.StatusBadRequest))
+		Expect(request("DELETE", "/admin/log-level", true).Code).To(Equal(http.StatusBadRequest))
+		Expect(logrus.GetLevel()).To(Equal(logrus.InfoLevel))
+	})
+
+	It("requires an auth handler", func() {
+		Expect(func() { svr.RegisterLogLevelEndpoint("/log-level", nil) }).To(Panic())
+	})
+})
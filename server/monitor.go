@@ -0,0 +1,84 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+)
+
+//allDependenciesKey is the cache key of the checks of all the version groups. The
+//version groups start with a slash, so it does not collide with them.
+const allDependenciesKey = "*"
+
+//StartHealthMonitor runs the dependency checks of all the detailed health
+//version groups every interval in the background, so that the history and the
+//transition handlers are updated without anyone requesting the health endpoints.
+//A check in multiple groups runs once. The Prometheus metrics use its results
+//when HealthCacheTTL is longer than the interval. Call the returned function to
+//stop it; it returns after the running checks finish. It panics if the interval
+//is not positive.
+func (s *Server) StartHealthMonitor(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		panic("Invalid health monitor interval. Must be positive")
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			checks := s.allDependencyChecks()
+			s.runChecks(allDependenciesKey, checks, allIndexes(checks), true)
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
+
+//recordResults adds the results of the dependency checks to the history and
+//the transitions if they are set
+func (s *Server) recordResults(deps []*health.DependencyInfo) {
+	now := time.Now()
+	for _, di := range deps {
+		if s.History != nil {
+			s.History.Record(di, now)
+		}
+		if s.Transitions != nil {
+			s.Transitions.Observe(di)
+		}
+	}
+}
+
+//allDependencyChecks returns the checks of all the version groups with unique names
+func (s *Server) allDependencyChecks() []health.HealthChecker {
+	keys := make([]string, 0, len(s.AdditionalHealthData))
+	for k := range s.AdditionalHealthData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var checks []health.HealthChecker
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if ahd := s.AdditionalHealthData[k]; ahd != nil {
+			for _, hc := range ahd.DependencyChecks {
+				if !seen[hc.GetName()] {
+					seen[hc.GetName()] = true
+					checks = append(checks, hc)
+				}
+			}
+		}
+	}
+	return checks
+}
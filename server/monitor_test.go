@@ -0,0 +1,64 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/gin-gonic/gin"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health monitor", func() {
+	It("runs the checks in the background and notifies the transitions", func() {
+		var mu sync.Mutex
+		var events []string
+		mysql := NewStatusCheck("mysql", health.OK)
+
+		svr := Server{Engine: gin.New(), History: health.NewHistory(0), Transitions: health.NewTransitions()}
+		svr.Transitions.OnChange(func(name, from, to string, info *health.DependencyInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, name+": "+from+" -> "+to)
+		})
+		svr.RegisterDetailedHealth("/v1", "v1", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{mysql, NewStatusCheck("redis", health.OK)},
+		})
+		svr.RegisterDetailedHealth("/v2", "v2", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{mysql},
+		})
+		Expect(svr.allDependencyChecks()).To(HaveLen(2))
+
+		stop := svr.StartHealthMonitor(5 * time.Millisecond)
+		defer stop()
+
+		Eventually(func() string { return svr.Transitions.Status("mysql") }).Should(Equal(health.OK))
+		mysql.SetStatus(health.CRIT)
+		Eventually(func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return events
+		}).Should(Equal([]string{"mysql: OK -> CRIT"}))
+		Expect(svr.History.Entries("redis", time.Time{}, time.Time{})).NotTo(BeEmpty())
+	})
+
+	It("stops after the running checks and rejects a non-positive interval", func() {
+		check := &CountingCheck{StatusCheck: NewStatusCheck("mysql", health.OK)}
+		svr := Server{Engine: gin.New()}
+		svr.RegisterDetailedHealth("/v1", "v1", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{check},
+		})
+
+		stop := svr.StartHealthMonitor(time.Millisecond)
+		Eventually(check.Count).Should(BeNumerically(">", 1))
+		stop()
+		count := check.Count()
+		time.Sleep(10 * time.Millisecond)
+		Expect(check.Count()).To(Equal(count))
+		stop()
+
+		Expect(func() { svr.StartHealthMonitor(0) }).To(Panic())
+	})
+})
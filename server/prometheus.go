@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/gin-gonic/gin"
+)
+
+//RegisterPrometheusMetrics registers the endpoint that exports the health in
+//the Prometheus text format. path defaults to "/metrics". A scrape runs the
+//dependency checks of all the detailed health version groups once, reusing their
+//results within HealthCacheTTL, like the results of StartHealthMonitor.
+//It does not affect the statsd metrics of the metrics package.
+func (s *Server) RegisterPrometheusMetrics(path string) {
+	s.RegisterPrometheusMetricsOn(s.Engine, path)
+}
+
+//RegisterPrometheusMetricsOn registers the Prometheus metrics endpoint on the
+//router, which can be a router group with any base path.
+func (s *Server) RegisterPrometheusMetricsOn(r gin.IRouter, path string) {
+	if path == "" {
+		path = "/metrics"
+	}
+	r.GET(path, s.prometheusMetrics)
+}
+
+func (s *Server) prometheusMetrics(c *gin.Context) {
+	checks := s.allDependencyChecks()
+	results := s.runChecks(allDependenciesKey, checks, allIndexes(checks), false)
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", health.PrometheusContentType)
+	if err := health.WritePrometheus(c.Writer, s.AppInfo, results, s.IsDraining()); err != nil {
+		c.Error(err)
+	}
+}
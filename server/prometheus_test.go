@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/gin-gonic/gin"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prometheus metrics", func() {
+	It("exports the health of all the version groups", func() {
+		svr := Server{Engine: gin.New(), AppInfo: &health.AppInfo{Version: "1.0.0"}}
+		svr.RegisterDetailedHealth("/v1", "v1", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{NewStatusCheck("mysql", health.OK)},
+		})
+		svr.RegisterDetailedHealth("/v2", "v2", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{NewStatusCheck("redis", health.WARN)},
+		})
+		svr.RegisterPrometheusMetrics("")
+
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		resp := httptest.NewRecorder()
+		svr.Engine.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal(health.PrometheusContentType))
+		body := resp.Body.String()
+		Expect(body).To(ContainSubstring("health_status 1\n"))
+		Expect(body).To(ContainSubstring(`health_build_info{app="",version="1.0.0",revision=""} 1`))
+		Expect(body).To(ContainSubstring(`health_dependency_status{name="mysql",type="service",criticality="required"} 0`))
+		Expect(body).To(ContainSubstring(`health_dependency_status{name="redis",type="service",criticality="required"} 1`))
+	})
+
+	It("reuses the cached results with the time they were checked", func() {
+		mysql := &CountingCheck{StatusCheck: NewStatusCheck("mysql", health.OK)}
+		svr := Server{Engine: gin.New(), HealthCacheTTL: time.Minute}
+		svr.RegisterDetailedHealth("/v1", "v1", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{mysql},
+		})
+		svr.RegisterPrometheusMetricsOn(svr.Engine.Group("/admin"), "")
+
+		scrape := func() string {
+			req, _ := http.NewRequest("GET", "/admin/metrics", nil)
+			resp := httptest.NewRecorder()
+			svr.Engine.ServeHTTP(resp, req)
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			return regexp.MustCompile(`health_dependency_last_check_timestamp_seconds{name="mysql",type="service"} (\S+)`).FindStringSubmatch(resp.Body.String())[1]
+		}
+		checkedAt := scrape()
+		time.Sleep(10 * time.Millisecond)
+		Expect(scrape()).To(Equal(checkedAt))
+		Expect(mysql.Count()).To(Equal(int32(1)))
+
+		//The results of the health monitor are reused too
+		stop := svr.StartHealthMonitor(time.Hour)
+		defer stop()
+		Eventually(mysql.Count).Should(Equal(int32(2)))
+		Eventually(scrape).ShouldNot(Equal(checkedAt))
+		Expect(mysql.Count()).To(Equal(int32(2)))
+	})
+})
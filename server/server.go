@@ -1,10 +1,11 @@
 package server
 
 import (
-	"fmt"
 	"net/http"
+	"path"
 	"regexp"
-	"strings"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/coupa/foundation-go/health"
@@ -12,6 +13,8 @@ import (
 )
 
 var (
+	//HealthTimeout is how long the dependency checks can run, unless the server
+	//sets HealthCheckTimeout
 	HealthTimeout = 5 * time.Second
 )
 
@@ -20,8 +23,33 @@ type Server struct {
 	Engine      *gin.Engine
 	AppInfo     *health.AppInfo
 	ProjectInfo *health.ProjectInfo
-	//The key is like "/v1" or "/v2" with a leading slash
+	//The key is the full path of the version group with a leading slash, like "/v1"
+	//or "/orders/v1" when it is registered on a router group
 	AdditionalHealthData map[string]*health.AdditionalHealthData
+	//History records the results of the dependency checks if it is not nil. It is
+	//created by RegisterHealthHistory if it is not set.
+	History *health.History
+	//Transitions is notified of the results of the dependency checks if it is not
+	//nil. Add the transition handlers with Transitions.OnChange, and the handlers of
+	//every result with Transitions.OnObserve.
+	Transitions *health.Transitions
+	//HealthCacheTTL caches the results of the dependency checks of the detailed
+	//health and the Prometheus metrics for this long. The query parameter
+	//"fresh=true" of the detailed health bypasses the cache. There is no cache if
+	//it is 0.
+	HealthCacheTTL time.Duration
+	//HealthCheckTimeout is how long the dependency checks can run. The checks that
+	//have not finished by then are CRIT. HealthTimeout is used if it is 0.
+	HealthCheckTimeout time.Duration
+	//MaxParallelChecks limits the number of dependency checks running at the same
+	//time. There is no limit if it is 0.
+	MaxParallelChecks int
+
+	//draining is 1 when the server is draining. See SetDraining.
+	draining int32
+
+	cacheMu sync.Mutex
+	cache   map[cacheKey]cachedResult
 }
 
 func (s *Server) UseMiddleware(mw gin.HandlerFunc) {
@@ -32,7 +60,26 @@ func (s *Server) UseMiddleware(mw gin.HandlerFunc) {
 //Simple health is used by the load balancer's health checks and dependent services'
 //detailed health.
 func (s *Server) RegisterSimpleHealth() {
-	s.Engine.GET("/health", s.simpleHealth)
+	s.RegisterSimpleHealthOn(s.Engine)
+}
+
+//RegisterSimpleHealthOn registers /health on the router, which can be a router
+//group with any base path, like svr.Engine.Group("/orders") for "/orders/health".
+func (s *Server) RegisterSimpleHealthOn(r gin.IRouter) {
+	r.GET("/health", s.simpleHealth)
+}
+
+//RegisterReadiness registers /health/ready for readiness probes, like those of
+//Kubernetes. It responds like /health, with 503 and CRIT while the server is
+//draining.
+func (s *Server) RegisterReadiness() {
+	s.RegisterReadinessOn(s.Engine)
+}
+
+//RegisterReadinessOn registers /health/ready on the router, which can be a router
+//group with any base path
+func (s *Server) RegisterReadinessOn(r gin.IRouter) {
+	r.GET("/health/ready", s.simpleHealth)
 }
 
 //RegisterDetailedHealth registers detail health at /<versionGroup>/health/detailed
@@ -43,13 +90,24 @@ func (s *Server) RegisterSimpleHealth() {
 //A detailed health should only check for other service's simple health. Never
 //check the detailed health of a depending service.
 func (s *Server) RegisterDetailedHealth(versionGroup, description string, h *health.AdditionalHealthData) {
-	//Accept only valid versionGroup, like "", "/", and "/v1"...
+	s.RegisterDetailedHealthOn(s.Engine, versionGroup, description, h)
+}
+
+//RegisterDetailedHealthOn registers detail health at /<versionGroup>/health/detailed
+//on the router, which can be a router group with any base path. For example, with
+//svr.Engine.Group("/orders") and "/v1", the endpoint is "/orders/v1/health/detailed".
+//The versionGroup is a label with a leading slash, like "/v1" or "/beta", or it
+//is "" or "/" for no version. The base path of the router joined with the label,
+//like "/orders/v1", is the key of s.AdditionalHealthData and the gRPC health
+//service name without the leading slash. It panics if the key is already used.
+func (s *Server) RegisterDetailedHealthOn(r gin.IRouter, versionGroup, description string, h *health.AdditionalHealthData) {
+	//Accept only valid versionGroup, like "", "/", "/v1", and "/beta"...
 	//This also makes versionGroup compatible for registering the route
 	if versionGroup != "" {
 		if versionGroup == "/" {
 			versionGroup = ""
-		} else if fine, _ := regexp.MatchString(`^/v\d+$`, versionGroup); !fine {
-			panic(`Invalid version group. Must be "", "/", or like "/v1" "/v2"...`)
+		} else if fine, _ := regexp.MatchString(`^/[A-Za-z0-9][A-Za-z0-9._-]*$`, versionGroup); !fine {
+			panic(`Invalid version group. Must be "", "/", or like "/v1" "/beta"...`)
 		}
 	}
 	if h == nil {
@@ -59,100 +117,91 @@ func (s *Server) RegisterDetailedHealth(versionGroup, description string, h *hea
 	if s.AdditionalHealthData == nil {
 		s.AdditionalHealthData = map[string]*health.AdditionalHealthData{}
 	}
-	if versionGroup == "" {
-		s.AdditionalHealthData["/"] = h
-	} else {
-		s.AdditionalHealthData[versionGroup] = h
+	base := "/"
+	if g, yes := r.(interface{ BasePath() string }); yes {
+		base = g.BasePath()
+	}
+	key := path.Join(base, versionGroup)
+	if s.AdditionalHealthData[key] != nil {
+		panic("Detailed health is already registered for " + key)
 	}
+	s.AdditionalHealthData[key] = h
 
-	s.Engine.GET(versionGroup+"/health/detailed", s.detailedHealth)
+	r.GET(versionGroup+"/health/detailed", s.detailedHealth(key, h))
 }
 
 func (s *Server) simpleHealth(c *gin.Context) {
+	if s.IsDraining() {
+		h := health.NewSimpleHealth(s.AppInfo, health.CRIT)
+		h.Details = DrainingDetails
+		c.JSON(http.StatusServiceUnavailable, h)
+		return
+	}
 	c.JSON(http.StatusOK, health.NewSimpleHealth(s.AppInfo, health.OK))
 }
 
-func (s *Server) detailedHealth(c *gin.Context) {
-	ver := extractVersionKey(c.Request.URL.Path)
-	ahd := new(health.AdditionalHealthData)
-	if s.AdditionalHealthData != nil && s.AdditionalHealthData[ver] != nil {
-		ahd = s.AdditionalHealthData[ver]
+//detailedHealth returns the handler that renders the detailed health of the
+//version group. The query parameters are:
+//  - check: the names of the checks to run, which can be repeated or comma-separated.
+//    An unknown name is a 400 error.
+//  - type: the types of the checks to run, like "service".
+//  - fresh=true: bypasses the cache of HealthCacheTTL.
+//  - verbose=false: omits the project info.
+func (s *Server) detailedHealth(ver string, ahd *health.AdditionalHealthData) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.renderDetailedHealth(c, ver, ahd)
+	}
+}
+
+func (s *Server) renderDetailedHealth(c *gin.Context, ver string, ahd *health.AdditionalHealthData) {
+	indexes, err := filterChecks(ahd.DependencyChecks, queryList(c, "check"), queryList(c, "type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 	h := health.NewDetailedHealth(s.AppInfo, s.ProjectInfo, ahd.Description)
+	if verbose, err := strconv.ParseBool(c.DefaultQuery("verbose", "true")); err == nil && !verbose {
+		h.Project = nil
+	}
 
 	if ahd.DataProvider != nil {
-		if extraData := ahd.DataProvider(c); extraData != nil {
-			for k, v := range extraData {
-				h[k] = v
-			}
-		}
+		h.Custom = ahd.DataProvider(c)
 	}
 
-	checks := ahd.DependencyChecks
-	if num := len(checks); num > 0 {
-		type wrapper struct {
-			di *health.DependencyInfo
-			hc health.HealthChecker
-		}
-		buffer := make(chan *wrapper, num)
-		hChecks := make([]health.HealthChecker, num)
-
-		for i, hc := range checks {
-			hChecks[i] = hc
-		}
-		for _, hc := range checks {
-			go func(healthCheck health.HealthChecker) {
-				buffer <- &wrapper{di: healthCheck.Check(), hc: healthCheck}
-			}(hc)
-		}
-
-	Label:
-		for i := 0; i < num; i++ {
-			select {
-			case w := <-buffer:
-				for i, hc := range hChecks {
-					if hc == w.hc {
-						hChecks[i] = nil
-						break
-					}
-				}
-				h.AddDependency(w.di)
-				if health.IsMoreCritical(w.di.State.Status, h["status"].(string)) {
-					h["status"] = w.di.State.Status
-				}
-			case <-time.After(HealthTimeout):
-				break Label
-			}
-		}
+	fresh, _ := strconv.ParseBool(c.Query("fresh"))
+	deps := dependencyInfos(s.runChecks(ver, ahd.DependencyChecks, indexes, fresh))
+	for _, di := range deps {
+		h.AddDependency(di)
+	}
+	status, reason := health.AggregateStatus(deps)
+	if s.IsDraining() {
+		status, reason = health.CRIT, health.CRIT+": the server is "+DrainingDetails
+	}
+	if health.IsMoreCritical(status, h.Status) {
+		h.Status = status
+	}
+	h.StatusReason = reason
 
-		//If there is any item in hChecks, it is timed out
-		for _, hc := range hChecks {
-			if hc == nil {
-				continue
-			}
-			h["status"] = health.CRIT
-			h.AddDependency(&health.DependencyInfo{
-				Name:         hc.GetName(),
-				Type:         hc.GetType(),
-				ResponseTime: HealthTimeout.Seconds(),
-				State: health.DependencyState{
-					Status:  health.CRIT,
-					Details: fmt.Sprintf("Health check timed out after %f seconds", HealthTimeout.Seconds()),
-				},
-			})
-		}
+	//The caches must keep the HTML and the JSON apart
+	c.Header("Vary", "Accept")
+	if wantsDashboard(c) {
+		renderDashboard(c, h)
+		return
 	}
 	c.JSON(http.StatusOK, h)
 }
 
-func extractVersionKey(path string) string {
-	regex, err := regexp.Compile(`^/*v\d+`)
-	if err != nil {
-		return ""
+//checkDependencies runs the health checks with the timeout and the parallelism
+//of the server. The results are in the same order as the checks.
+func (s *Server) checkDependencies(checks []health.HealthChecker) []*health.DependencyInfo {
+	timeout := s.HealthCheckTimeout
+	if timeout <= 0 {
+		timeout = HealthTimeout
 	}
-	v := regex.FindString(path)
-	if !strings.HasPrefix(v, "/") {
-		v = "/" + v
+	//HealthTimeout 0 times out the checks right away, as it always did, while the
+	//executor waits for all the checks without a timeout
+	if timeout <= 0 {
+		timeout = time.Nanosecond
 	}
-	return v
+	return health.CheckExecutor{Timeout: timeout, MaxParallel: s.MaxParallelChecks}.Run(checks)
 }
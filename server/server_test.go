@@ -56,10 +56,10 @@ var _ = Describe("Server", func() {
 
 		It("makes a server with middleware and detailed health", func() {
 			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				h := health.Health{
-					"status":   health.WARN,
-					"version":  "fakeVer",
-					"revision": "fakeRev",
+				h := health.SimpleHealth{
+					Status:   health.WARN,
+					Version:  "fakeVer",
+					Revision: "fakeRev",
 				}
 				d, _ := json.Marshal(h)
 				fmt.Fprint(w, string(d))
@@ -114,7 +114,7 @@ var _ = Describe("Server", func() {
 			Expect(resp.Header().Get("X-Correlation-Id")).NotTo(BeEmpty())
 			d, _ := ioutil.ReadAll(resp.Body)
 
-			var h health.Health
+			var h map[string]interface{}
 			json.Unmarshal(d, &h)
 			Expect(h["status"]).To(Equal("CRIT"))
 			Expect(len(h["dependencies"].([]interface{}))).To(Equal(2))
@@ -140,10 +140,10 @@ var _ = Describe("Server", func() {
 		Describe("Status of detailed health", func() {
 			It("should depend on the dependencies' statuses", func() {
 				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-					h := health.Health{
-						"status":   health.WARN,
-						"version":  "fakeVer",
-						"revision": "fakeRev",
+					h := health.SimpleHealth{
+						Status:   health.WARN,
+						Version:  "fakeVer",
+						Revision: "fakeRev",
 					}
 					d, _ := json.Marshal(h)
 					fmt.Fprint(w, string(d))
@@ -177,7 +177,7 @@ var _ = Describe("Server", func() {
 				Expect(resp.Code).To(Equal(http.StatusOK))
 				d, _ := ioutil.ReadAll(resp.Body)
 
-				var h health.Health
+				var h map[string]interface{}
 				json.Unmarshal(d, &h)
 				Expect(h["status"]).To(Equal("WARN"))
 				Expect(len(h["dependencies"].([]interface{}))).To(Equal(1))
@@ -189,6 +189,55 @@ var _ = Describe("Server", func() {
 			})
 		})
 
+		Describe("Criticality of dependencies", func() {
+			It("applies the criticality to the status and explains it", func() {
+				svr := Server{Engine: gin.New()}
+				custom := health.AdditionalHealthData{
+					DependencyChecks: []health.HealthChecker{
+						NewStatusCheck("mysql", health.OK),
+						health.Optional(NewStatusCheck("analytics", health.CRIT)),
+						health.Informational(NewStatusCheck("metrics", health.CRIT)),
+					},
+				}
+				svr.RegisterDetailedHealth("/v1", "This is v1 detailed health", &custom)
+
+				req, _ := http.NewRequest("GET", "/v1/health/detailed", nil)
+				resp := httptest.NewRecorder()
+				svr.Engine.ServeHTTP(resp, req)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				var h map[string]interface{}
+				json.Unmarshal(resp.Body.Bytes(), &h)
+				Expect(h["status"]).To(Equal(health.WARN))
+				Expect(h["statusReason"]).To(Equal("WARN: optional dependency `analytics` is CRIT and counts as WARN"))
+				Expect(h["dependencies"]).To(HaveLen(3))
+			})
+		})
+
+		Describe("Custom data of detailed health", func() {
+			It("adds the custom data without overriding the standard fields", func() {
+				svr := Server{Engine: gin.New(), AppInfo: &health.AppInfo{Version: "v1"}}
+				svr.RegisterDetailedHealth("/v1", "This is v1 detailed health", &health.AdditionalHealthData{
+					DependencyChecks: []health.HealthChecker{NewStatusCheck("mysql", health.WARN)},
+					DataProvider: func(c *gin.Context) map[string]interface{} {
+						return map[string]interface{}{"status": 123, "version": nil, "custom": "data"}
+					},
+				})
+
+				req, _ := http.NewRequest("GET", "/v1/health/detailed", nil)
+				resp := httptest.NewRecorder()
+				svr.Engine.ServeHTTP(resp, req)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				h, err := health.DecodeHealth(resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(h.Status).To(Equal(health.WARN))
+				Expect(h.Version).To(Equal("v1"))
+				Expect(h.Dependencies).To(HaveLen(1))
+				Expect(h.Custom).To(Equal(map[string]interface{}{"custom": "data"}))
+			})
+		})
+
 		Describe("Timeout", func() {
 			AfterEach(func() {
 				HealthTimeout = 5 * time.Second
@@ -222,7 +271,7 @@ var _ = Describe("Server", func() {
 				Expect(resp.Code).To(Equal(http.StatusOK))
 				d, _ := ioutil.ReadAll(resp.Body)
 
-				var h health.Health
+				var h map[string]interface{}
 				json.Unmarshal(d, &h)
 				Expect(h["status"]).To(Equal(health.CRIT))
 				Expect(len(h["dependencies"].([]interface{}))).To(Equal(3))
@@ -243,6 +292,29 @@ var _ = Describe("Server", func() {
 				}
 				Expect(processed).To(HaveLen(3))
 			})
+
+			It("uses the timeout of the server and keeps the order of the checks", func() {
+				svr := Server{Engine: gin.New(), HealthCheckTimeout: 50 * time.Millisecond, MaxParallelChecks: 2}
+				svr.RegisterDetailedHealth("/v1", "This is v1 detailed health", &health.AdditionalHealthData{
+					DependencyChecks: []health.HealthChecker{SlowCheck{Name: "slow"}, PanicCheck{Name: "panicky"}, NewStatusCheck("mysql", health.OK)},
+				})
+
+				req, _ := http.NewRequest("GET", "/v1/health/detailed", nil)
+				resp := httptest.NewRecorder()
+				svr.Engine.ServeHTTP(resp, req)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				h, err := health.DecodeHealth(resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(h.Status).To(Equal(health.CRIT))
+				Expect(h.Dependencies).To(HaveLen(3))
+				Expect(h.Dependencies[0].Name).To(Equal("slow"))
+				Expect(h.Dependencies[0].State.Details).To(HavePrefix("Health check timed out after 0.05"))
+				Expect(h.Dependencies[1].Name).To(Equal("panicky"))
+				Expect(h.Dependencies[1].State).To(Equal(health.DependencyState{Status: health.CRIT, Details: "Health check panicked: boom"}))
+				Expect(h.Dependencies[2].Name).To(Equal("mysql"))
+				Expect(h.Dependencies[2].State.Status).To(Equal(health.OK))
+			})
 		})
 
 		Describe("RegisterDetailedHealth", func() {
@@ -257,6 +329,15 @@ var _ = Describe("Server", func() {
 				svr.RegisterDetailedHealth("bad", "", nil)
 			})
 
+			It("panics on a version group with a slash inside", func() {
+				defer func() {
+					Expect(recover()).NotTo(BeNil())
+				}()
+
+				svr := Server{}
+				svr.RegisterDetailedHealth("/v1/health", "", nil)
+			})
+
 			It("does not panic on accepted version group", func() {
 				svr := Server{
 					Engine: gin.New(),
@@ -270,7 +351,7 @@ var _ = Describe("Server", func() {
 				Expect(resp.Code).To(Equal(http.StatusOK))
 
 				d, _ := ioutil.ReadAll(resp.Body)
-				var h health.Health
+				var h map[string]interface{}
 				json.Unmarshal(d, &h)
 				Expect(h["description"].(string)).To(Equal("slash"))
 
@@ -280,7 +361,7 @@ var _ = Describe("Server", func() {
 				Expect(resp.Code).To(Equal(http.StatusOK))
 
 				d, _ = ioutil.ReadAll(resp.Body)
-				var h2 health.Health
+				var h2 map[string]interface{}
 				json.Unmarshal(d, &h2)
 				Expect(h2["description"].(string)).To(Equal("10"))
 			})
@@ -305,7 +386,7 @@ var _ = Describe("Server", func() {
 				Expect(resp.Code).To(Equal(http.StatusOK))
 
 				d, _ := ioutil.ReadAll(resp.Body)
-				var h health.Health
+				var h map[string]interface{}
 				json.Unmarshal(d, &h)
 				Expect(h["description"].(string)).To(Equal("hello"))
 
@@ -315,7 +396,7 @@ var _ = Describe("Server", func() {
 				Expect(resp.Code).To(Equal(http.StatusOK))
 
 				d, _ = ioutil.ReadAll(resp.Body)
-				var h2 health.Health
+				var h2 map[string]interface{}
 				json.Unmarshal(d, &h2)
 				Expect(h2["description"].(string)).To(Equal("pizza"))
 
@@ -325,28 +406,85 @@ var _ = Describe("Server", func() {
 				Expect(resp.Code).To(Equal(http.StatusOK))
 
 				d, _ = ioutil.ReadAll(resp.Body)
-				var h3 health.Health
+				var h3 map[string]interface{}
 				json.Unmarshal(d, &h3)
 				Expect(h3["description"].(string)).To(Equal("empty"))
 			})
-		})
-	})
 
-	Describe("extractVersionKey", func() {
-		It("", func() {
-			//Versioned paths return the version
-			Expect(extractVersionKey("/v1/some")).To(Equal("/v1"))
-			Expect(extractVersionKey("/v10/some")).To(Equal("/v10"))
-
-			Expect(extractVersionKey("/v1")).To(Equal("/v1"))
-			Expect(extractVersionKey("v1")).To(Equal("/v1"))
-			Expect(extractVersionKey("v1/some")).To(Equal("/v1"))
-
-			//Non-versioned path returns a slash
-			Expect(extractVersionKey("health")).To(Equal("/"))
-			Expect(extractVersionKey("/health")).To(Equal("/"))
-			Expect(extractVersionKey("/health/some")).To(Equal("/"))
-			Expect(extractVersionKey("")).To(Equal("/"))
+			It("mounts the health on a router group with any version label", func() {
+				svr := Server{Engine: gin.New()}
+				orders := svr.Engine.Group("/orders")
+				svr.RegisterSimpleHealthOn(orders)
+				svr.RegisterDetailedHealthOn(orders, "/v1", "orders v1", &health.AdditionalHealthData{
+					DependencyChecks: []health.HealthChecker{NewStatusCheck("mysql", health.WARN)},
+				})
+				svr.RegisterDetailedHealthOn(orders.Group("/api"), "/beta", "orders beta", nil)
+				svr.RegisterHealthHistoryOn(orders)
+
+				req, _ := http.NewRequest("GET", "/orders/health", nil)
+				resp := httptest.NewRecorder()
+				svr.Engine.ServeHTTP(resp, req)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				req, _ = http.NewRequest("GET", "/orders/v1/health/detailed", nil)
+				resp = httptest.NewRecorder()
+				svr.Engine.ServeHTTP(resp, req)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				h, err := health.DecodeHealth(resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(h.Description).To(Equal("orders v1"))
+				Expect(h.Status).To(Equal(health.WARN))
+				Expect(h.Dependencies).To(HaveLen(1))
+
+				req, _ = http.NewRequest("GET", "/orders/api/beta/health/detailed", nil)
+				resp = httptest.NewRecorder()
+				svr.Engine.ServeHTTP(resp, req)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				h, err = health.DecodeHealth(resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(h.Description).To(Equal("orders beta"))
+				Expect(h.Dependencies).To(BeEmpty())
+
+				req, _ = http.NewRequest("GET", "/orders/health/history?name=mysql", nil)
+				resp = httptest.NewRecorder()
+				svr.Engine.ServeHTTP(resp, req)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				Expect(svr.AdditionalHealthData).To(HaveKey("/orders/v1"))
+				Expect(svr.AdditionalHealthData).To(HaveKey("/orders/api/beta"))
+			})
+
+			It("keeps the same version label on different router groups apart", func() {
+				svr := Server{Engine: gin.New(), HealthCacheTTL: time.Minute}
+				svr.RegisterDetailedHealthOn(svr.Engine.Group("/orders"), "/v1", "orders", &health.AdditionalHealthData{
+					DependencyChecks: []health.HealthChecker{NewStatusCheck("mysql", health.WARN)},
+				})
+				svr.RegisterDetailedHealthOn(svr.Engine.Group("/payments"), "/v1", "payments", &health.AdditionalHealthData{
+					DependencyChecks: []health.HealthChecker{NewStatusCheck("redis", health.OK)},
+				})
+
+				for _, group := range []string{"orders", "payments", "orders"} {
+					req, _ := http.NewRequest("GET", "/"+group+"/v1/health/detailed", nil)
+					resp := httptest.NewRecorder()
+					svr.Engine.ServeHTTP(resp, req)
+					Expect(resp.Code).To(Equal(http.StatusOK))
+					h, err := health.DecodeHealth(resp.Body)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(h.Description).To(Equal(group))
+					Expect(h.Dependencies).To(HaveLen(1))
+					if group == "orders" {
+						Expect(h.Dependencies[0].Name).To(Equal("mysql"))
+					} else {
+						Expect(h.Dependencies[0].Name).To(Equal("redis"))
+					}
+				}
+				Expect(svr.AdditionalHealthData).To(HaveKey("/orders/v1"))
+				Expect(svr.AdditionalHealthData).To(HaveKey("/payments/v1"))
+
+				Expect(func() {
+					svr.RegisterDetailedHealthOn(svr.Engine.Group("/orders"), "/v1", "again", nil)
+				}).To(Panic())
+			})
 		})
 	})
 })
@@ -367,3 +505,21 @@ func (sc SlowCheck) GetName() string {
 func (sc SlowCheck) GetType() string {
 	return "service"
 }
+
+//PanicCheck panics when checked. The map makes it non-comparable.
+type PanicCheck struct {
+	Name   string
+	Labels map[string]string
+}
+
+func (pc PanicCheck) Check() *health.DependencyInfo {
+	panic("boom")
+}
+
+func (pc PanicCheck) GetName() string {
+	return pc.Name
+}
+
+func (pc PanicCheck) GetType() string {
+	return "service"
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/coupa/foundation-go/health"
+)
+
+//ServiceStatus returns the status, OK, WARN or CRIT, of a service name of the
+//health services of other protocols, like the gRPC health service of the
+//server/grpchealth package. The service names are:
+//  - "": the server itself, which is OK like the simple health, or CRIT when the
+//    server is draining.
+//  - A detailed health version group with or without the leading slash, like
+//    "v1" or "orders/v1": the aggregated status of the group's dependency checks.
+//  - The name of a dependency check, like "mysql": the status of that check
+//    regardless of its criticality.
+//The results are cached for HealthCacheTTL with those of the detailed health. It
+//returns false if the service is unknown.
+func (s *Server) ServiceStatus(service string) (string, bool) {
+	if service == "" {
+		if s.IsDraining() {
+			return health.CRIT, true
+		}
+		return health.OK, true
+	}
+	key, index, found := s.serviceKey(service)
+	if !found {
+		return "", false
+	}
+	checks := s.AdditionalHealthData[key].DependencyChecks
+	if index >= 0 {
+		return s.runChecks(key, checks, []int{index}, false)[0].State.Status, true
+	}
+	status, _ := health.AggregateStatus(dependencyInfos(s.runChecks(key, checks, allIndexes(checks), false)))
+	return status, true
+}
+
+//serviceKey finds the version group of a service name, and the index of the
+//check if it is the name of a dependency check, or -1. It returns false if the
+//service is unknown.
+func (s *Server) serviceKey(service string) (string, int, bool) {
+	key := "/" + strings.TrimPrefix(service, "/")
+	if ahd := s.AdditionalHealthData[key]; ahd != nil {
+		return key, -1, true
+	}
+
+	//Look for the check by name in the version groups in a stable order
+	keys := make([]string, 0, len(s.AdditionalHealthData))
+	for k := range s.AdditionalHealthData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if ahd := s.AdditionalHealthData[k]; ahd != nil {
+			for i, hc := range ahd.DependencyChecks {
+				if hc.GetName() == service {
+					return k, i, true
+				}
+			}
+		}
+	}
+	return "", -1, false
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coupa/foundation-go/health"
+	"github.com/gin-gonic/gin"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ServiceStatus", func() {
+	It("reports the server, the version groups and the dependency checks", func() {
+		mysql := &CountingCheck{StatusCheck: NewStatusCheck("mysql", health.OK)}
+		svr := Server{Engine: gin.New()}
+		svr.RegisterDetailedHealthOn(svr.Engine.Group("/orders"), "/v1", "v1", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{mysql, health.Optional(NewStatusCheck("redis", health.CRIT))},
+		})
+
+		for service, expected := range map[string]string{"": health.OK, "orders/v1": health.WARN, "/orders/v1": health.WARN, "mysql": health.OK, "redis": health.CRIT} {
+			status, found := svr.ServiceStatus(service)
+			Expect(found).To(BeTrue(), service)
+			Expect(status).To(Equal(expected), service)
+		}
+		_, found := svr.ServiceStatus("v1")
+		Expect(found).To(BeFalse())
+
+		svr.SetDraining(true)
+		status, _ := svr.ServiceStatus("")
+		Expect(status).To(Equal(health.CRIT))
+	})
+
+	It("shares the cached results with the detailed health", func() {
+		mysql := &CountingCheck{StatusCheck: NewStatusCheck("mysql", health.OK)}
+		svr := Server{Engine: gin.New(), HealthCacheTTL: time.Minute}
+		svr.RegisterDetailedHealth("/v1", "v1", &health.AdditionalHealthData{
+			DependencyChecks: []health.HealthChecker{mysql},
+		})
+		svr.ServiceStatus("v1")
+		svr.ServiceStatus("mysql")
+		Expect(mysql.Count()).To(Equal(int32(1)))
+	})
+})
+
+//StatusCheck reports a preset status that can be changed concurrently
+type StatusCheck struct {
+	Name string
+
+	mu     sync.Mutex
+	status string
+}
+
+func NewStatusCheck(name, status string) *StatusCheck {
+	return &StatusCheck{Name: name, status: status}
+}
+
+func (sc *StatusCheck) SetStatus(status string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.status = status
+}
+
+func (sc *StatusCheck) Check() *health.DependencyInfo {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return &health.DependencyInfo{Name: sc.Name, Type: "service", State: health.DependencyState{Status: sc.status}}
+}
+
+func (sc *StatusCheck) GetName() string {
+	return sc.Name
+}
+
+func (sc *StatusCheck) GetType() string {
+	return "service"
+}